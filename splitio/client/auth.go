@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/splitio/go-client/splitio/conf"
+)
+
+// AuthError is returned by BlockUntilReady once Split servers reject the apikey. The factory stops synchronizing
+// and won't become ready again, so a new factory with a valid apikey is needed
+type AuthError struct {
+	Method string
+	URL    string
+	Code   int
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("SDK authentication failed: %s %s returned status %d", e.Method, e.URL, e.Code)
+}
+
+// authWatcher reports requests rejected by Split servers because of the apikey to the factory
+type authWatcher struct {
+	factory *SplitFactory
+	mutex   sync.Mutex
+}
+
+// watch returns a copy of cfg whose requests are checked for authentication failures before going through the
+// interceptors supplied by the user
+func (w *authWatcher) watch(cfg *conf.SplitSdkConfig) *conf.SplitSdkConfig {
+	watched := *cfg
+	watched.Advanced.HTTPInterceptors = append([]conf.HTTPInterceptor{w.intercept}, cfg.Advanced.HTTPInterceptors...)
+	return &watched
+}
+
+// attach sets the factory to be notified of authentication failures
+func (w *authWatcher) attach(factory *SplitFactory) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.factory = factory
+}
+
+func (w *authWatcher) intercept(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return resp, err
+	}
+
+	w.mutex.Lock()
+	factory := w.factory
+	w.mutex.Unlock()
+	if factory == nil {
+		return resp, err
+	}
+	// Requests sent before an apikey rotation are rejected once the previous apikey is revoked, which is expected
+	if !factory.credentials.isCurrent(req.Header.Get("Authorization")) {
+		factory.logger.Debug("Ignoring rejected request sent with a previous apikey: ", req.Method, " ", req.URL.Path)
+		return resp, err
+	}
+	factory.failAuthentication(&AuthError{Method: req.Method, URL: req.URL.Path, Code: resp.StatusCode})
+	return resp, err
+}
+
+// credentials holds the apikey sent by a factory to Split servers, which can be rotated while it runs
+type credentials struct {
+	apikey   atomic.Value
+	provider func() string
+}
+
+func newCredentials(apikey string) *credentials {
+	c := &credentials{}
+	c.apikey.Store(apikey)
+	return c
+}
+
+// provide returns a copy of cfg whose requests are sent with the current apikey, unless the user supplied a
+// provider of their own
+func (c *credentials) provide(cfg *conf.SplitSdkConfig) *conf.SplitSdkConfig {
+	provided := *cfg
+	c.provider = cfg.Advanced.APIKeyProvider
+	if c.provider == nil {
+		provided.Advanced.APIKeyProvider = c.get
+	}
+	return &provided
+}
+
+func (c *credentials) get() string {
+	return c.apikey.Load().(string)
+}
+
+func (c *credentials) set(apikey string) {
+	c.apikey.Store(apikey)
+}
+
+// isCurrent returns true if an Authorization header carries the apikey currently sent to Split servers
+func (c *credentials) isCurrent(authorization string) bool {
+	apikey := c.get()
+	if c.provider != nil {
+		if provided := c.provider(); provided != "" {
+			apikey = provided
+		}
+	}
+	return authorization == "Bearer "+apikey
+}
@@ -1,15 +1,19 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"runtime/debug"
 	"time"
 
+	"github.com/splitio/go-client/splitio/engine"
 	"github.com/splitio/go-client/splitio/engine/evaluator"
 	"github.com/splitio/go-client/splitio/engine/evaluator/impressionlabels"
 	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	"github.com/splitio/go-client/splitio/impressions"
 	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-client/splitio/storage/redisdb"
 	"github.com/splitio/go-client/splitio/util/metrics"
 	"github.com/splitio/go-toolkit/logging"
 )
@@ -19,11 +23,13 @@ type SplitClient struct {
 	logger             logging.LoggerInterface
 	evaluator          evaluator.Interface
 	impressions        storage.ImpressionStorageProducer
+	impressionsCount   storage.ImpressionsCountStorageProducer
 	metrics            storage.MetricsStorageProducer
 	events             storage.EventStorageProducer
 	validator          inputValidation
 	factory            *SplitFactory
 	impressionListener *impressionlistener.WrapperImpressionListener
+	impressionObserver *impressions.Observer
 }
 
 // TreatmentResult struct that includes the Treatment evaluation with the corresponding Config
@@ -32,8 +38,81 @@ type TreatmentResult struct {
 	Config    *string `json:"config"`
 }
 
+// TreatmentDetails struct that includes the Treatment evaluation with the corresponding Config along with the
+// information needed to explain how it was decided
+type TreatmentDetails struct {
+	TreatmentResult
+	Label                 string `json:"label"`
+	ChangeNumber          int64  `json:"changeNumber"`
+	EvaluationTimeNs      int64  `json:"evaluationTimeNs"`
+	MatchedConditionIndex int    `json:"matchedConditionIndex"`
+	Killed                bool   `json:"killed"`
+}
+
+// controlDetails returns the details of a control treatment that was not the result of an evaluation
+func controlDetails(label string) TreatmentDetails {
+	return TreatmentDetails{
+		TreatmentResult: TreatmentResult{
+			Treatment: evaluator.Control,
+			Config:    nil,
+		},
+		Label:                 label,
+		MatchedConditionIndex: engine.NoConditionIndex,
+	}
+}
+
+// evaluationDetails builds the details of an evaluation result
+func evaluationDetails(result *evaluator.Result) TreatmentDetails {
+	return TreatmentDetails{
+		TreatmentResult: TreatmentResult{
+			Treatment: result.Treatment,
+			Config:    result.Config,
+		},
+		Label:                 result.Label,
+		ChangeNumber:          result.SplitChangeNumber,
+		EvaluationTimeNs:      result.EvaluationTimeNs,
+		MatchedConditionIndex: result.MatchedConditionIndex,
+		Killed:                result.Killed,
+	}
+}
+
+// withContext returns a copy of the client whose redis storages are bound to ctx, so that every lookup & write made
+// on behalf of a call gives up once ctx is done. In-memory storages never block, so they're used as they are
+func (c *SplitClient) withContext(ctx context.Context) *SplitClient {
+	if ctx.Done() == nil {
+		return c
+	}
+	splitStorage, ok := c.factory.storages.splits.(*redisdb.RedisSplitStorage)
+	if !ok {
+		return c
+	}
+
+	bound := *c
+	boundSplits := splitStorage.WithContext(ctx)
+	segmentStorage := c.factory.storages.segments
+	if redisSegments, ok := segmentStorage.(*redisdb.RedisSegmentStorage); ok {
+		segmentStorage = redisSegments.WithContext(ctx)
+	}
+	bound.evaluator = evaluator.NewEvaluator(boundSplits, segmentStorage, engine.NewEngine(c.logger), c.logger)
+	bound.validator.splitStorage = boundSplits
+	if impressions, ok := c.impressions.(*redisdb.RedisImpressionStorage); ok {
+		bound.impressions = impressions.WithContext(ctx)
+	}
+	if impressionsCount, ok := c.impressionsCount.(*redisdb.RedisImpressionsCountStorage); ok {
+		bound.impressionsCount = impressionsCount.WithContext(ctx)
+	}
+	if metrics, ok := c.metrics.(*redisdb.RedisMetricsStorage); ok {
+		bound.metrics = metrics.WithContext(ctx)
+	}
+	if events, ok := c.events.(*redisdb.RedisEventsStorage); ok {
+		bound.events = events.WithContext(ctx)
+	}
+	return &bound
+}
+
 // getEvaluationResult calls evaluation for one particular split
 func (c *SplitClient) getEvaluationResult(
+	ctx context.Context,
 	matchingKey string,
 	bucketingKey *string,
 	feature string,
@@ -41,37 +120,61 @@ func (c *SplitClient) getEvaluationResult(
 	operation string,
 ) *evaluator.Result {
 	if c.isReady() {
-		return c.evaluator.EvaluateFeature(matchingKey, bucketingKey, feature, attributes)
+		// Storages bound to ctx fail once it's done, so evaluations that outlive it can't be trusted
+		if ctx.Err() == nil {
+			result := c.evaluator.EvaluateFeature(matchingKey, bucketingKey, feature, attributes)
+			if ctx.Err() == nil {
+				return result
+			}
+		}
+		c.logger.Warning(operation + ": context done before the evaluation finished, returning CONTROL")
+		return &evaluator.Result{
+			Treatment:             evaluator.Control,
+			Label:                 impressionlabels.ContextExpired,
+			MatchedConditionIndex: engine.NoConditionIndex,
+		}
 	}
 	c.logger.Warning(operation + ": the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
 	return &evaluator.Result{
-		Treatment: evaluator.Control,
-		Label:     impressionlabels.ClientNotReady,
-		Config:    nil,
+		Treatment:             evaluator.Control,
+		Label:                 impressionlabels.ClientNotReady,
+		Config:                nil,
+		MatchedConditionIndex: engine.NoConditionIndex,
 	}
 }
 
 // getEvaluationsResult calls evaluation for multiple treatments at once
 func (c *SplitClient) getEvaluationsResult(
+	ctx context.Context,
 	matchingKey string,
 	bucketingKey *string,
 	features []string,
 	attributes map[string]interface{},
 	operation string,
 ) evaluator.Results {
+	label := impressionlabels.ClientNotReady
 	if c.isReady() {
-		return c.evaluator.EvaluateFeatures(matchingKey, bucketingKey, features, attributes)
+		if ctx.Err() == nil {
+			results := c.evaluator.EvaluateFeatures(matchingKey, bucketingKey, features, attributes)
+			if ctx.Err() == nil {
+				return results
+			}
+		}
+		c.logger.Warning(operation + ": context done before the evaluation finished, returning CONTROL")
+		label = impressionlabels.ContextExpired
+	} else {
+		c.logger.Warning(operation + ": the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
 	}
-	c.logger.Warning(operation + ": the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
 	result := evaluator.Results{
 		EvaluationTimeNs: 0,
 		Evaluations:      make(map[string]evaluator.Result),
 	}
 	for _, feature := range features {
 		result.Evaluations[feature] = evaluator.Result{
-			Treatment: evaluator.Control,
-			Label:     impressionlabels.ClientNotReady,
-			Config:    nil,
+			Treatment:             evaluator.Control,
+			Label:                 label,
+			Config:                nil,
+			MatchedConditionIndex: engine.NoConditionIndex,
 		}
 	}
 	return result
@@ -108,14 +211,28 @@ func (c *SplitClient) createImpression(
 }
 
 // storeData stores impression, runs listener and stores metrics
-func (c *SplitClient) storeData(impressions []storage.Impression, attributes map[string]interface{}, metricsLabel string, evaluationTimeNs int64) {
+func (c *SplitClient) storeData(impressionsToStore []storage.Impression, attributes map[string]interface{}, metricsLabel string, evaluationTimeNs int64) {
 	// Store impression
-	if c.impressions != nil {
-		c.impressions.LogImpressions(impressions)
+	if c.impressionsCount != nil {
+		// In count mode, only how many impressions were generated is kept
+		c.impressionsCount.IncCounts(impressions.CountByTimeFrame(impressionsToStore))
+
+		if c.impressionListener != nil {
+			c.impressionListener.SendDataToClient(impressionsToStore, attributes)
+		}
+	} else if c.impressions != nil {
+		toLog := impressionsToStore
+		if c.impressionObserver != nil {
+			// In optimized mode, impressions already seen within the current window are only sent to the listener
+			toLog = c.impressionObserver.Process(impressionsToStore)
+		}
+		if len(toLog) > 0 {
+			c.impressions.LogImpressions(toLog)
+		}
 
 		// Custom Impression Listener
 		if c.impressionListener != nil {
-			c.impressionListener.SendDataToClient(impressions, attributes)
+			c.impressionListener.SendDataToClient(impressionsToStore, attributes)
 		}
 	} else {
 		c.logger.Warning("No impression storage set in client. Not sending impressions!")
@@ -133,16 +250,14 @@ func (c *SplitClient) storeData(impressions []storage.Impression, attributes map
 // doTreatmentCall retrieves treatments of an specific feature with configurations object if it is present
 // for a certain key and set of attributes
 func (c *SplitClient) doTreatmentCall(
+	ctx context.Context,
 	key interface{},
 	feature string,
 	attributes map[string]interface{},
 	operation string,
 	metricsLabel string,
-) (t TreatmentResult) {
-	controlTreatment := TreatmentResult{
-		Treatment: evaluator.Control,
-		Config:    nil,
-	}
+) (t TreatmentDetails) {
+	controlTreatment := controlDetails("")
 
 	// Set up a guard deferred function to recover if the SDK starts panicking
 	defer func() {
@@ -153,7 +268,7 @@ func (c *SplitClient) doTreatmentCall(
 				"SDK is panicking with the following error", r, "\n",
 				string(debug.Stack()), "\n",
 				"Returning CONTROL", "\n")
-			t = controlTreatment
+			t = controlDetails(impressionlabels.Exception)
 		}
 	}()
 
@@ -174,49 +289,56 @@ func (c *SplitClient) doTreatmentCall(
 		return controlTreatment
 	}
 
-	evaluationResult := c.getEvaluationResult(matchingKey, bucketingKey, feature, attributes, operation)
+	client := c.withContext(ctx)
+	evaluationResult := client.getEvaluationResult(ctx, matchingKey, bucketingKey, feature, attributes, operation)
 
 	if !c.validator.IsSplitFound(evaluationResult.Label, feature, operation) {
-		return controlTreatment
+		return controlDetails(evaluationResult.Label)
 	}
 
-	c.storeData(
+	client.storeData(
 		[]storage.Impression{c.createImpression(feature, bucketingKey, evaluationResult.Label, matchingKey, evaluationResult.Treatment, evaluationResult.SplitChangeNumber)},
 		attributes,
 		metricsLabel,
 		evaluationResult.EvaluationTimeNs,
 	)
 
-	return TreatmentResult{
-		Treatment: evaluationResult.Treatment,
-		Config:    evaluationResult.Config,
-	}
+	return evaluationDetails(evaluationResult)
 }
 
 // Treatment implements the main functionality of split. Retrieve treatments of a specific feature
 // for a certain key and set of attributes
 func (c *SplitClient) Treatment(key interface{}, feature string, attributes map[string]interface{}) string {
-	return c.doTreatmentCall(key, feature, attributes, "Treatment", "sdk.getTreatment").Treatment
+	return c.doTreatmentCall(context.Background(), key, feature, attributes, "Treatment", "sdk.getTreatment").Treatment
+}
+
+// TreatmentCtx behaves like Treatment, but redis lookups & writes give up once ctx is cancelled or its deadline is
+// exceeded. Evaluations that outlive ctx return CONTROL and their impression carries the "context expired" label
+func (c *SplitClient) TreatmentCtx(ctx context.Context, key interface{}, feature string, attributes map[string]interface{}) string {
+	return c.doTreatmentCall(ctx, key, feature, attributes, "TreatmentCtx", "sdk.getTreatment").Treatment
 }
 
 // TreatmentWithConfig implements the main functionality of split. Retrieves the treatment of a specific feature with
 // the corresponding configuration if it is present
 func (c *SplitClient) TreatmentWithConfig(key interface{}, feature string, attributes map[string]interface{}) TreatmentResult {
-	return c.doTreatmentCall(key, feature, attributes, "TreatmentWithConfig", "sdk.getTreatmentWithConfig")
+	return c.doTreatmentCall(context.Background(), key, feature, attributes, "TreatmentWithConfig", "sdk.getTreatmentWithConfig").TreatmentResult
+}
+
+// TreatmentWithDetails retrieves the treatment of a specific feature with the corresponding configuration if it is
+// present, along with the label, change number, evaluation time, matched condition and kill status that explain it
+func (c *SplitClient) TreatmentWithDetails(key interface{}, feature string, attributes map[string]interface{}) TreatmentDetails {
+	return c.doTreatmentCall(context.Background(), key, feature, attributes, "TreatmentWithDetails", "sdk.getTreatmentWithDetails")
 }
 
 // Generates control treatments
-func (c *SplitClient) generateControlTreatments(features []string, operation string) map[string]TreatmentResult {
-	treatments := make(map[string]TreatmentResult)
+func (c *SplitClient) generateControlTreatments(features []string, operation string) map[string]TreatmentDetails {
+	treatments := make(map[string]TreatmentDetails)
 	filtered, err := c.validator.ValidateFeatureNames(features, operation)
 	if err != nil {
 		return treatments
 	}
 	for _, feature := range filtered {
-		treatments[feature] = TreatmentResult{
-			Treatment: evaluator.Control,
-			Config:    nil,
-		}
+		treatments[feature] = controlDetails("")
 	}
 	return treatments
 }
@@ -224,13 +346,14 @@ func (c *SplitClient) generateControlTreatments(features []string, operation str
 // doTreatmentsCall retrieves treatments of an specific array of features with configurations object if it is present
 // for a certain key and set of attributes
 func (c *SplitClient) doTreatmentsCall(
+	ctx context.Context,
 	key interface{},
 	features []string,
 	attributes map[string]interface{},
 	operation string,
 	metricsLabel string,
-) (t map[string]TreatmentResult) {
-	treatments := make(map[string]TreatmentResult)
+) (t map[string]TreatmentDetails) {
+	treatments := make(map[string]TreatmentDetails)
 
 	// Set up a guard deferred function to recover if the SDK starts panicking
 	defer func() {
@@ -258,28 +381,23 @@ func (c *SplitClient) doTreatmentsCall(
 	filteredFeatures, err := c.validator.ValidateFeatureNames(features, operation)
 	if err != nil {
 		c.logger.Error(err.Error())
-		return map[string]TreatmentResult{}
+		return map[string]TreatmentDetails{}
 	}
 
 	var bulkImpressions []storage.Impression
-	evaluationsResult := c.getEvaluationsResult(matchingKey, bucketingKey, filteredFeatures, attributes, operation)
+	client := c.withContext(ctx)
+	evaluationsResult := client.getEvaluationsResult(ctx, matchingKey, bucketingKey, filteredFeatures, attributes, operation)
 	for feature, evaluation := range evaluationsResult.Evaluations {
 		if !c.validator.IsSplitFound(evaluation.Label, feature, operation) {
-			treatments[feature] = TreatmentResult{
-				Treatment: evaluator.Control,
-				Config:    nil,
-			}
+			treatments[feature] = controlDetails(evaluation.Label)
 		} else {
 			bulkImpressions = append(bulkImpressions, c.createImpression(feature, bucketingKey, evaluation.Label, matchingKey, evaluation.Treatment, evaluation.SplitChangeNumber))
 
-			treatments[feature] = TreatmentResult{
-				Treatment: evaluation.Treatment,
-				Config:    evaluation.Config,
-			}
+			treatments[feature] = evaluationDetails(&evaluation)
 		}
 	}
 
-	c.storeData(bulkImpressions, attributes, metricsLabel, evaluationsResult.EvaluationTimeNs)
+	client.storeData(bulkImpressions, attributes, metricsLabel, evaluationsResult.EvaluationTimeNs)
 
 	return treatments
 }
@@ -287,7 +405,18 @@ func (c *SplitClient) doTreatmentsCall(
 // Treatments evaluates multiple featers for a single user and set of attributes at once
 func (c *SplitClient) Treatments(key interface{}, features []string, attributes map[string]interface{}) map[string]string {
 	treatments := map[string]string{}
-	result := c.doTreatmentsCall(key, features, attributes, "Treatments", "sdk.getTreatments")
+	result := c.doTreatmentsCall(context.Background(), key, features, attributes, "Treatments", "sdk.getTreatments")
+	for feature, treatmentResult := range result {
+		treatments[feature] = treatmentResult.Treatment
+	}
+	return treatments
+}
+
+// TreatmentsCtx behaves like Treatments, but redis lookups & writes give up once ctx is cancelled or its deadline
+// is exceeded. Evaluations that outlive ctx return CONTROL for every feature
+func (c *SplitClient) TreatmentsCtx(ctx context.Context, key interface{}, features []string, attributes map[string]interface{}) map[string]string {
+	treatments := map[string]string{}
+	result := c.doTreatmentsCall(ctx, key, features, attributes, "TreatmentsCtx", "sdk.getTreatments")
 	for feature, treatmentResult := range result {
 		treatments[feature] = treatmentResult.Treatment
 	}
@@ -296,7 +425,18 @@ func (c *SplitClient) Treatments(key interface{}, features []string, attributes
 
 // TreatmentsWithConfig evaluates multiple featers for a single user and set of attributes at once and returns configurations
 func (c *SplitClient) TreatmentsWithConfig(key interface{}, features []string, attributes map[string]interface{}) map[string]TreatmentResult {
-	return c.doTreatmentsCall(key, features, attributes, "TreatmentsWithConfig", "sdk.getTreatmentsWithConfig")
+	treatments := map[string]TreatmentResult{}
+	result := c.doTreatmentsCall(context.Background(), key, features, attributes, "TreatmentsWithConfig", "sdk.getTreatmentsWithConfig")
+	for feature, treatmentDetails := range result {
+		treatments[feature] = treatmentDetails.TreatmentResult
+	}
+	return treatments
+}
+
+// TreatmentsWithDetails evaluates multiple featers for a single user and set of attributes at once and returns
+// configurations along with the label, change number, evaluation time, matched condition and kill status of each one
+func (c *SplitClient) TreatmentsWithDetails(key interface{}, features []string, attributes map[string]interface{}) map[string]TreatmentDetails {
+	return c.doTreatmentsCall(context.Background(), key, features, attributes, "TreatmentsWithDetails", "sdk.getTreatmentsWithDetails")
 }
 
 // isDestroyed returns true if the client has been destroyed
@@ -323,6 +463,31 @@ func (c *SplitClient) Track(
 	eventType string,
 	value interface{},
 	properties map[string]interface{},
+) error {
+	return c.doTrackCall(context.Background(), key, trafficType, eventType, value, properties)
+}
+
+// TrackCtx behaves like Track, but redis operations give up once ctx is cancelled or its deadline is exceeded, in
+// which case the context's error is returned and the event is not queued
+func (c *SplitClient) TrackCtx(
+	ctx context.Context,
+	key string,
+	trafficType string,
+	eventType string,
+	value interface{},
+	properties map[string]interface{},
+) error {
+	return c.doTrackCall(ctx, key, trafficType, eventType, value, properties)
+}
+
+// doTrackCall validates and queues an event
+func (c *SplitClient) doTrackCall(
+	ctx context.Context,
+	key string,
+	trafficType string,
+	eventType string,
+	value interface{},
+	properties map[string]interface{},
 ) (ret error) {
 
 	defer func() {
@@ -346,6 +511,24 @@ func (c *SplitClient) Track(
 		c.logger.Warning("Track: the SDK is not ready, results may be incorrect. Make sure to wait for SDK readiness before using this method")
 	}
 
+	if ctx.Err() == nil {
+		err := c.withContext(ctx).track(key, trafficType, eventType, value, properties)
+		if err == nil || ctx.Err() == nil {
+			return err
+		}
+	}
+	c.logger.Warning("Track: context done before the event was queued")
+	return ctx.Err()
+}
+
+// track validates the event and pushes it into the events storage
+func (c *SplitClient) track(
+	key string,
+	trafficType string,
+	eventType string,
+	value interface{},
+	properties map[string]interface{},
+) error {
 	key, trafficType, eventType, value, err := c.validator.ValidateTrackInputs(
 		key,
 		trafficType,
@@ -384,3 +567,8 @@ func (c *SplitClient) Track(
 func (c *SplitClient) BlockUntilReady(timer int) error {
 	return c.factory.BlockUntilReady(timer)
 }
+
+// BlockUntilReadyCtx Calls BlockUntilReadyCtx on factory to block client on readiness
+func (c *SplitClient) BlockUntilReadyCtx(ctx context.Context) error {
+	return c.factory.BlockUntilReadyCtx(ctx)
+}
@@ -2,12 +2,17 @@ package client
 
 import (
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -17,11 +22,15 @@ import (
 	"github.com/splitio/go-client/splitio/engine/evaluator"
 	"github.com/splitio/go-client/splitio/engine/evaluator/impressionlabels"
 	impressionlistener "github.com/splitio/go-client/splitio/impressionListener"
+	"github.com/splitio/go-client/splitio/impressions"
 	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/service/local"
+	"github.com/splitio/go-client/splitio/splittest"
 	"github.com/splitio/go-client/splitio/storage"
 	"github.com/splitio/go-client/splitio/storage/mutexmap"
 	"github.com/splitio/go-client/splitio/storage/mutexqueue"
 	"github.com/splitio/go-client/splitio/storage/redisdb"
+	"github.com/splitio/go-client/splitio/storage/snapshot"
 	"github.com/splitio/go-toolkit/asynctask"
 	"github.com/splitio/go-toolkit/datastructures/set"
 	"github.com/splitio/go-toolkit/logging"
@@ -210,6 +219,169 @@ func TestTreatments(t *testing.T) {
 	expectedTreatment(res["notFeature"], evaluator.Control, t)
 }
 
+func TestClientOptimizedImpressions(t *testing.T) {
+	factory := getFactory()
+	factory.impressionObserver = impressions.NewObserver(impressions.DefaultObserverSize)
+	client := factory.Client()
+	client.evaluator = &mockEvaluator{}
+	factory.status.Store(sdkStatusReady)
+
+	for i := 0; i < 5; i++ {
+		expectedTreatment(client.Treatment("key", "feature", nil), "TreatmentA", t)
+	}
+	client.Treatments("key", []string{"feature", "feature2"}, nil)
+
+	impressionsQueue := client.impressions.(storage.ImpressionStorage)
+	queued, _ := impressionsQueue.PopN(cfg.Advanced.ImpressionsBulkSize)
+	if len(queued) != 2 {
+		t.Error("Only one impression per distinct key/feature/treatment should be queued. Got: ", queued)
+	}
+}
+
+func TestClientImpressionsCount(t *testing.T) {
+	factory := getFactory()
+	countStorage := mutexmap.NewMMImpressionsCountStorage()
+	factory.storages.impressionsCount = countStorage
+	client := factory.Client()
+	client.evaluator = &mockEvaluator{}
+	factory.status.Store(sdkStatusReady)
+
+	for i := 0; i < 3; i++ {
+		expectedTreatment(client.Treatment("key", "feature", nil), "TreatmentA", t)
+	}
+
+	impressionsQueue := client.impressions.(storage.ImpressionStorage)
+	queued, _ := impressionsQueue.PopN(cfg.Advanced.ImpressionsBulkSize)
+	if len(queued) != 0 {
+		t.Error("No impressions should be queued in count mode")
+	}
+
+	counts := countStorage.PopCounts()
+	if len(counts) != 1 || counts[0].FeatureName != "feature" || counts[0].RawCount != 3 {
+		t.Error("Impressions should have been counted. Got: ", counts)
+	}
+}
+
+func TestTreatmentWithDetails(t *testing.T) {
+	factory := getFactory()
+	client := factory.Client()
+	client.evaluator = &mockEvaluator{}
+	factory.status.Store(sdkStatusReady)
+
+	details := client.TreatmentWithDetails("key", "feature", nil)
+	if details.Treatment != "TreatmentA" || details.Label != "aLabel" || details.ChangeNumber != 123 {
+		t.Error("Wrong details returned: ", details)
+	}
+
+	details = client.TreatmentWithDetails("key", "notFeature", nil)
+	if details.Treatment != evaluator.Control || details.Label != impressionlabels.SplitNotFound {
+		t.Error("Missing split details should have the not found label: ", details)
+	}
+
+	details = client.TreatmentWithDetails(nil, "feature", nil)
+	if details.Treatment != evaluator.Control || details.MatchedConditionIndex != -1 {
+		t.Error("Invalid key should return control: ", details)
+	}
+
+	client.evaluator = &mockEventsPanic{}
+	details = client.TreatmentWithDetails("key", "feature", nil)
+	if details.Treatment != evaluator.Control || details.Label != impressionlabels.Exception {
+		t.Error("Panics should be reported with the exception label: ", details)
+	}
+}
+
+func TestTreatmentsWithDetails(t *testing.T) {
+	factory := getFactory()
+	client := factory.Client()
+	client.evaluator = &mockEvaluator{}
+	factory.status.Store(sdkStatusReady)
+
+	res := client.TreatmentsWithDetails("user1", []string{"feature", "notFeature"}, nil)
+	if res["feature"].Treatment != "TreatmentA" || res["feature"].Label != "aLabel" || res["feature"].ChangeNumber != 123 {
+		t.Error("Wrong details returned: ", res["feature"])
+	}
+	if res["notFeature"].Treatment != evaluator.Control || res["notFeature"].Label != impressionlabels.SplitNotFound {
+		t.Error("Missing split details should have the not found label: ", res["notFeature"])
+	}
+}
+
+type mockSlowEvaluator struct {
+	mockEvaluator
+	delay time.Duration
+}
+
+func (e *mockSlowEvaluator) EvaluateFeature(
+	key string,
+	bucketingKey *string,
+	feature string,
+	attributes map[string]interface{},
+) *evaluator.Result {
+	time.Sleep(e.delay)
+	return e.mockEvaluator.EvaluateFeature(key, bucketingKey, feature, attributes)
+}
+
+func (e *mockSlowEvaluator) EvaluateFeatures(
+	key string,
+	bucketingKey *string,
+	features []string,
+	attributes map[string]interface{},
+) evaluator.Results {
+	time.Sleep(e.delay)
+	return e.mockEvaluator.EvaluateFeatures(key, bucketingKey, features, attributes)
+}
+
+func TestTreatmentCtx(t *testing.T) {
+	factory := getFactory()
+	client := factory.Client()
+	client.evaluator = &mockSlowEvaluator{delay: 200 * time.Millisecond}
+	factory.status.Store(sdkStatusReady)
+
+	expectedTreatment(client.TreatmentCtx(context.Background(), "key", "feature", nil), "TreatmentA", t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	expectedTreatment(client.TreatmentCtx(ctx, "key", "feature", nil), evaluator.Control, t)
+
+	impressionsQueue := client.impressions.(storage.ImpressionStorage)
+	impressions, _ := impressionsQueue.PopN(cfg.Advanced.ImpressionsBulkSize)
+	if len(impressions) != 2 || impressions[1].Label != impressionlabels.ContextExpired {
+		t.Error("Expired evaluations should be logged with the context expired label. Got: ", impressions)
+	}
+
+	res := client.TreatmentsCtx(ctx, "key", []string{"feature", "feature2"}, nil)
+	expectedTreatment(res["feature"], evaluator.Control, t)
+	expectedTreatment(res["feature2"], evaluator.Control, t)
+
+	client.evaluator = &mockEventsPanic{}
+	expectedTreatment(client.TreatmentCtx(context.Background(), "key", "feature", nil), evaluator.Control, t)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	expectedTreatment(client.TreatmentCtx(ctx2, "key", "feature", nil), evaluator.Control, t)
+}
+
+func TestTrackCtx(t *testing.T) {
+	factory := getFactory()
+	client := factory.Client()
+	events := mutexqueue.NewMQEventsStorage(10, make(chan string, 1), logging.NewLogger(nil))
+	client.events = events
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.TrackCtx(ctx, "key", "user", "event", nil, nil); err != context.Canceled {
+		t.Error("Canceled error should be returned. Got: ", err)
+	}
+	if events.Count() != 0 {
+		t.Error("Events should not be queued once the context is done")
+	}
+
+	if err := client.TrackCtx(context.Background(), "key", "user", "event", nil, nil); err != nil {
+		t.Error("No error should be returned. Got: ", err)
+	}
+	if events.Count() != 1 {
+		t.Error("Event should be queued")
+	}
+}
+
 func TestLocalhostMode(t *testing.T) {
 	file, err := ioutil.TempFile("", "splitio_tests")
 	if err != nil {
@@ -599,6 +771,28 @@ func TestBlockUntilReadyWrongTimerPassed(t *testing.T) {
 	}
 }
 
+func TestBlockUntilReadyCtx(t *testing.T) {
+	factory := getFactory()
+	factory.readinessSubscriptors = make(map[int]chan int)
+	factory.status.Store(sdkStatusInitializing)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := factory.Client().BlockUntilReadyCtx(ctx); err != context.DeadlineExceeded {
+		t.Error("Deadline exceeded error should be returned. Got: ", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		factory.broadcastReadiness(sdkStatusReady)
+	}()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel2()
+	if err := factory.Manager().BlockUntilReadyCtx(ctx2); err != nil {
+		t.Error("No error should be returned once the sdk is ready. Got: ", err)
+	}
+}
+
 func TestBlockUntilReadyStatusLocalhost(t *testing.T) {
 	file, err := ioutil.TempFile("", "splitio_tests")
 	if err != nil {
@@ -1285,6 +1479,64 @@ func TestLocalhostModeYAML(t *testing.T) {
 	expectedTreatmentAndConfig(resultTreatmentsWithConfig["other_feature"], "control", "", t)
 }
 
+func TestLocalhostModeInvalidYAML(t *testing.T) {
+	file, err := ioutil.TempFile("", "splitio_tests*.yaml")
+	if err != nil {
+		t.Error("Couldn't create temporary file for localhost client tests: ", err)
+		return
+	}
+	defer os.Remove(file.Name())
+
+	file.Write([]byte("- feature1:\n    treatment: \"on\"\n- feature2:\n    keys: \"key\"\n"))
+	file.Close()
+
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = file.Name()
+	factory, _ := NewSplitFactory("localhost", sdkConf)
+	defer factory.Destroy()
+
+	start := time.Now()
+	err = factory.Client().BlockUntilReady(5)
+	if err == nil || time.Since(start) > 2*time.Second {
+		t.Error("Initialization should fail as soon as the file is found invalid. Got: ", err)
+	}
+
+	report, ok := factory.InitializationError().(*local.ValidationReport)
+	if !ok || len(report.Errors) != 1 || report.Errors[0].Entry != 1 {
+		t.Error("Validation report should be available through the factory. Got: ", factory.InitializationError())
+	}
+
+	if factory.IsReady() {
+		t.Error("Factory should not be ready")
+	}
+}
+
+func TestLocalhostModeJSON(t *testing.T) {
+	sdkConf := conf.Default()
+	sdkConf.SplitFile = "../../testdata/splits.json"
+	sdkConf.SegmentDirectory = "../../testdata/segments"
+	factory, _ := NewSplitFactory("localhost", sdkConf)
+	client := factory.Client()
+	manager := factory.Manager()
+
+	err := client.BlockUntilReady(5)
+	if err != nil || !client.isReady() {
+		t.Error("Localhost should be ready", err)
+	}
+
+	if len(manager.Splits()) != 2 {
+		t.Error("Error grabbing splits for localhost mode")
+	}
+
+	expectedTreatmentAndConfig(client.TreatmentWithConfig("employee_1", "segment_feature", nil), "on", "{\"color\": \"blue\"}", t)
+	expectedTreatment(client.Treatment("someone_else", "segment_feature", nil), "off", t)
+	expectedTreatment(client.Treatment("whitelisted_user", "whitelist_feature", nil), "on", t)
+	expectedTreatment(client.Treatment("someone_else", "whitelist_feature", nil), "off", t)
+	expectedTreatment(client.Treatment("employee_1", "nonexistent_feature", nil), "control", t)
+
+	factory.Destroy()
+}
+
 func getRedisConfWithIP(IPAddressesEnabled bool) *redisdb.PrefixedRedisClient {
 	// Create prefixed client for adding Split
 	prefixedClient, _ := redisdb.NewPrefixedRedisClient(&conf.RedisConfig{
@@ -1536,3 +1788,716 @@ func TestClientWithIPDisabled(t *testing.T) {
 		return
 	}
 }
+
+func TestAuthFailureDuringInitialization(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.SetStatus(splittest.SplitChangesPath, http.StatusUnauthorized)
+
+	cfg := conf.Default()
+	server.Configure(cfg)
+	factory, err := NewSplitFactory("revoked", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer factory.Destroy()
+
+	err = factory.BlockUntilReady(5)
+	authError, ok := err.(*AuthError)
+	if !ok || authError.Code != http.StatusUnauthorized || authError.URL != splittest.SplitChangesPath {
+		t.Error("Authentication error should be returned. Got: ", err)
+	}
+	if factory.AuthenticationError() == nil || factory.IsReady() {
+		t.Error("Factory should be in authentication failed status")
+	}
+	if _, ok := factory.BlockUntilReady(1).(*AuthError); !ok {
+		t.Error("Authentication error should be returned once the factory failed")
+	}
+}
+
+func TestReadinessBroadcast(t *testing.T) {
+	factory := &SplitFactory{readinessSubscriptors: make(map[int]chan int)}
+	factory.status.Store(sdkStatusInitializing)
+	subscriptor := make(chan int, 1)
+	factory.subscribe(0, subscriptor)
+
+	done := make(chan struct{})
+	go func() {
+		factory.broadcastReadiness(sdkAuthenticationFailed)
+		factory.broadcastReadiness(sdkInitializationFailed)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Broadcasts should not block on subscriptors that already got a message")
+	}
+	if status := <-subscriptor; status != sdkAuthenticationFailed {
+		t.Error("First message should be kept. Got: ", status)
+	}
+
+	factory.authError = &AuthError{Code: http.StatusUnauthorized}
+	factory.broadcastReadiness(sdkInitializationFailed)
+	select {
+	case status := <-subscriptor:
+		t.Error("Initialization failures should not be broadcast after an authentication failure. Got: ", status)
+	default:
+	}
+}
+
+func TestAuthFailureAfterReady(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.PutSplits(dtos.SplitDTO{
+		Name:             "feature",
+		ChangeNumber:     10,
+		Status:           "ACTIVE",
+		TrafficTypeName:  "user",
+		DefaultTreatment: "on",
+		Conditions: []dtos.ConditionDTO{{
+			ConditionType: "ROLLOUT",
+			MatcherGroup: dtos.MatcherGroupDTO{
+				Combiner: "AND",
+				Matchers: []dtos.MatcherDTO{{MatcherType: "ALL_KEYS"}},
+			},
+			Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+		}},
+	})
+
+	cfg := conf.Default()
+	cfg.TaskPeriods.SplitSync = 1
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("revoked", cfg)
+	defer factory.Destroy()
+	client := factory.Client()
+	if err := client.BlockUntilReady(5); err != nil {
+		t.Fatal(err)
+	}
+	if treatment := client.Treatment("key", "feature", nil); treatment != "on" {
+		t.Error("Treatment should be evaluated. Got: ", treatment)
+	}
+
+	server.SetStatus(splittest.SplitChangesPath, http.StatusForbidden)
+	for start := time.Now(); factory.AuthenticationError() == nil && time.Since(start) < 5*time.Second; {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if factory.AuthenticationError() == nil || factory.IsReady() {
+		t.Error("Factory should move into authentication failed status")
+	}
+	if treatment := client.Treatment("key", "feature", nil); treatment != "control" {
+		t.Error("Control should be returned once authentication failed. Got: ", treatment)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if factory.tasks.splits.IsRunning() || factory.tasks.impressions.IsRunning() {
+		t.Error("Synchronization should have been stopped")
+	}
+}
+
+type mockStatusTransport struct {
+	status int
+}
+
+func (m *mockStatusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: m.status, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestAuthFailureWithRotatedKey(t *testing.T) {
+	factory := &SplitFactory{
+		credentials:           newCredentials("previous-key"),
+		logger:                logging.NewLogger(nil),
+		readinessSubscriptors: make(map[int]chan int),
+	}
+	watcher := &authWatcher{}
+	watcher.attach(factory)
+	factory.credentials.set("current-key")
+
+	req, _ := http.NewRequest("GET", "https://sdk.split.io/api/splitChanges", nil)
+	req.Header.Set("Authorization", "Bearer previous-key")
+	watcher.intercept(req, &mockStatusTransport{status: http.StatusUnauthorized})
+	if factory.AuthenticationError() != nil {
+		t.Error("Requests rejected with a rotated apikey should be ignored")
+	}
+
+	req.Header.Set("Authorization", "Bearer current-key")
+	watcher.intercept(req, &mockStatusTransport{status: http.StatusUnauthorized})
+	if factory.AuthenticationError() == nil {
+		t.Error("Requests rejected with the current apikey should fail authentication")
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+
+	var lastAuthorization atomic.Value
+	cfg := conf.Default()
+	cfg.TaskPeriods.SplitSync = 1
+	cfg.Advanced.HTTPInterceptors = []conf.HTTPInterceptor{
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			lastAuthorization.Store(req.Header.Get("Authorization"))
+			return next.RoundTrip(req)
+		},
+	}
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("rotated-key-1", cfg)
+	defer factory.Destroy()
+	if err := factory.BlockUntilReady(5); err != nil {
+		t.Fatal(err)
+	}
+	client := factory.Client()
+	if err := client.Track("key", "user", "click", 1.0, nil); err != nil {
+		t.Error(err)
+	}
+
+	if factory.RotateAPIKey("") == nil {
+		t.Error("Empty apikeys should be rejected")
+	}
+	if err := factory.RotateAPIKey("rotated-key-2"); err != nil {
+		t.Error("Apikey should be rotated", err)
+	}
+	for start := time.Now(); lastAuthorization.Load() != "Bearer rotated-key-2" && time.Since(start) < 3*time.Second; {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if lastAuthorization.Load() != "Bearer rotated-key-2" {
+		t.Error("Requests should be sent with the new apikey. Got: ", lastAuthorization.Load())
+	}
+
+	mutex.Lock()
+	_, previousTracked := factoryInstances["rotated-key-1"]
+	_, currentTracked := factoryInstances["rotated-key-2"]
+	mutex.Unlock()
+	if previousTracked || !currentTracked {
+		t.Error("Factory should be tracked under the new apikey")
+	}
+
+	// Data queued before the rotation is either still queued or already posted
+	queued := factory.storages.events.(storage.EventsStorage).Count()
+	if !factory.IsReady() || queued+int64(len(server.Events())) != 1 {
+		t.Error("Factory state should be kept across rotations")
+	}
+}
+
+func TestRotateAPIKeyRejected(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.SetAPIKeyStatus("revoked-key", http.StatusUnauthorized)
+
+	var lastAuthorization atomic.Value
+	cfg := conf.Default()
+	cfg.TaskPeriods.SplitSync = 1
+	cfg.Advanced.HTTPInterceptors = []conf.HTTPInterceptor{
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			if req.URL.Path == splittest.SplitChangesPath {
+				lastAuthorization.Store(req.Header.Get("Authorization"))
+			}
+			return next.RoundTrip(req)
+		},
+	}
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("kept-key", cfg)
+	defer factory.Destroy()
+	if err := factory.BlockUntilReady(5); err != nil {
+		t.Fatal(err)
+	}
+
+	err := factory.RotateAPIKey("revoked-key")
+	if httpError, ok := err.(*dtos.HTTPError); !ok || httpError.Code != http.StatusUnauthorized {
+		t.Error("Apikeys rejected with 401 should not be rotated. Got: ", err)
+	}
+
+	lastAuthorization.Store("")
+	for start := time.Now(); lastAuthorization.Load() == "" && time.Since(start) < 3*time.Second; {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if lastAuthorization.Load() != "Bearer kept-key" {
+		t.Error("Requests should keep being sent with the previous apikey. Got: ", lastAuthorization.Load())
+	}
+	if factory.AuthenticationError() != nil || !factory.IsReady() {
+		t.Error("Factory should keep running after a rejected rotation")
+	}
+}
+
+func TestSyncNow(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.UpdateSegment("employees", []string{"key1"}, nil)
+	server.PutSplits(dtos.SplitDTO{
+		Name:             "feature",
+		ChangeNumber:     10,
+		TrafficTypeName:  "user",
+		DefaultTreatment: "off",
+		Conditions: []dtos.ConditionDTO{{
+			ConditionType: "WHITELIST",
+			MatcherGroup: dtos.MatcherGroupDTO{
+				Combiner: "AND",
+				Matchers: []dtos.MatcherDTO{{
+					MatcherType:        "IN_SEGMENT",
+					UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"},
+				}},
+			},
+			Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+		}},
+	})
+
+	cfg := conf.Default()
+	cfg.Advanced.StreamingEnabled = false
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("manual-sync", cfg)
+	defer factory.Destroy()
+	client := factory.Client()
+	if err := client.BlockUntilReady(5); err != nil {
+		t.Fatal(err)
+	}
+	status := factory.SyncStatus()
+	if status[SyncTaskSplits].LastSuccess.IsZero() || status[SyncTaskSegments].LastSuccess.IsZero() {
+		t.Error("Initial synchronization should be reported", status)
+	}
+
+	server.UpdateSegment("employees", []string{"key2"}, nil)
+	server.KillSplit("feature", "killed")
+	result, err := factory.SyncNow(context.Background())
+	if err != nil || result.SplitsChangeNumber != 11 || result.SegmentsChangeNumbers["employees"] != 2 {
+		t.Error("Latest change numbers should be returned", result, err)
+	}
+	if treatment := client.Treatment("key2", "feature", nil); treatment != "killed" {
+		t.Error("Changes should be applied without waiting for the next period. Got: ", treatment)
+	}
+
+	server.SetStatus(splittest.SplitChangesPath, http.StatusInternalServerError)
+	if _, err = factory.SyncNow(context.Background()); err == nil {
+		t.Error("Failed synchronizations should be reported")
+	}
+	status = factory.SyncStatus()
+	if status[SyncTaskSplits].LastError == nil || status[SyncTaskSplits].LastFailure.IsZero() {
+		t.Error("Failure should be recorded", status[SyncTaskSplits])
+	}
+	if _, ok := status[SyncTaskImpressionsCount]; ok {
+		t.Error("Unused tasks should not be reported")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = factory.SyncNow(ctx); err != context.Canceled {
+		t.Error("Context errors should be returned. Got: ", err)
+	}
+}
+
+func TestFactoryEvents(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.PutSplits(dtos.SplitDTO{Name: "feature", ChangeNumber: 10, TrafficTypeName: "user", DefaultTreatment: "on"})
+
+	cfg := conf.Default()
+	cfg.Advanced.StreamingEnabled = false
+	cfg.BlockUntilReady = 1
+	cfg.Advanced.HTTPInterceptors = []conf.HTTPInterceptor{
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			// Initialization outlives the ready timeout
+			if req.URL.Path == splittest.SplitChangesPath && req.URL.Query().Get("since") == "-1" {
+				time.Sleep(1500 * time.Millisecond)
+			}
+			return next.RoundTrip(req)
+		},
+	}
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("events", cfg)
+
+	emitted := make(chan string, 10)
+	subscribe := func(event string) {
+		if err := factory.On(event, func() { emitted <- event }); err != nil {
+			t.Error(err)
+		}
+	}
+	expect := func(event string) {
+		select {
+		case received := <-emitted:
+			if received != event {
+				t.Errorf("%s should have been emitted. Got: %s", event, received)
+			}
+		case <-time.After(3 * time.Second):
+			t.Errorf("%s should have been emitted", event)
+		}
+	}
+
+	subscribe(SdkReady)
+	subscribe(SdkReadyTimedOut)
+	subscribe(SdkUpdate)
+	subscribe(SdkDestroyed)
+	if factory.On("SDK_UNKNOWN", func() {}) == nil || factory.On(SdkReady, nil) == nil {
+		t.Error("Unknown events & nil callbacks should be rejected")
+	}
+
+	expect(SdkReadyTimedOut)
+	expect(SdkReady)
+	if !factory.IsReady() {
+		t.Error("Factory should be ready")
+	}
+
+	server.KillSplit("feature", "off")
+	if _, err := factory.SyncNow(context.Background()); err != nil {
+		t.Error(err)
+	}
+	expect(SdkUpdate)
+
+	if _, err := factory.SyncNow(context.Background()); err != nil {
+		t.Error(err)
+	}
+	select {
+	case event := <-emitted:
+		t.Error("Nothing should be emitted without changes. Got: ", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Late subscriptions to SdkReady are called right away
+	subscribe(SdkReady)
+	expect(SdkReady)
+
+	factory.Destroy()
+	expect(SdkDestroyed)
+	factory.Destroy()
+	select {
+	case event := <-emitted:
+		t.Error("Destroy should be emitted once. Got: ", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSplitChangeListeners(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.PutSplits(
+		dtos.SplitDTO{Name: "feature", ChangeNumber: 10, TrafficTypeName: "user", DefaultTreatment: "on"},
+		dtos.SplitDTO{Name: "other", ChangeNumber: 10, TrafficTypeName: "user", DefaultTreatment: "on"},
+	)
+
+	cfg := conf.Default()
+	cfg.Advanced.StreamingEnabled = false
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("split-changes", cfg)
+	defer factory.Destroy()
+
+	all := make(chan SplitChange, 10)
+	filtered := make(chan SplitChange, 10)
+	factory.OnSplitChange(func(change SplitChange) { all <- change })
+	factory.OnSplitChange(func(change SplitChange) { filtered <- change }, "feature")
+	if factory.OnSplitChange(nil) == nil {
+		t.Error("Nil listeners should be rejected")
+	}
+	if err := factory.BlockUntilReady(5); err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func(changes chan SplitChange) *SplitChange {
+		select {
+		case change := <-changes:
+			return &change
+		case <-time.After(3 * time.Second):
+			return nil
+		}
+	}
+
+	server.KillSplit("feature", "off")
+	factory.SyncNow(context.Background())
+	change := receive(all)
+	if change == nil || change.Name != "feature" || change.Previous == nil || change.Current == nil {
+		t.Fatal("Kill should be delivered with both definitions", change)
+	}
+	if change.Previous.Killed || change.Previous.DefaultTreatment != "on" || !change.Current.Killed || change.Current.DefaultTreatment != "off" {
+		t.Error("Unexpected definitions", *change.Previous, *change.Current)
+	}
+	if change = receive(filtered); change == nil || change.Name != "feature" {
+		t.Error("Kill should be delivered to listeners of the split", change)
+	}
+
+	server.PutSplits(dtos.SplitDTO{Name: "other", TrafficTypeName: "user", DefaultTreatment: "on", Configurations: map[string]string{"on": "{}"}})
+	server.RemoveSplit("feature")
+	factory.SyncNow(context.Background())
+	changes := map[string]*SplitChange{}
+	for i := 0; i < 2; i++ {
+		if change = receive(all); change != nil {
+			changes[change.Name] = change
+		}
+	}
+	if other := changes["other"]; other == nil || len(other.Previous.Configs) != 0 || other.Current.Configs["on"] != "{}" {
+		t.Error("Config changes should be delivered", other)
+	}
+	if feature := changes["feature"]; feature == nil || feature.Previous == nil || feature.Current != nil {
+		t.Error("Archived splits should be delivered without current definition", feature)
+	}
+	if change = receive(filtered); change == nil || change.Name != "feature" || change.Current != nil {
+		t.Error("Archival should be delivered to listeners of the split", change)
+	}
+	select {
+	case change := <-filtered:
+		t.Error("Changes of other splits should not be delivered", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSplitChangeOrder(t *testing.T) {
+	emitter := newEventEmitter()
+	emitter.emit(SdkReady)
+
+	var mutex sync.Mutex
+	received := make([]string, 0)
+	emitter.onSplitChange(func(change SplitChange) {
+		if len(received) == 0 {
+			// A slow first delivery should not let later batches overtake it
+			time.Sleep(50 * time.Millisecond)
+		}
+		mutex.Lock()
+		received = append(received, change.Current.DefaultTreatment)
+		mutex.Unlock()
+	}, nil)
+
+	for _, treatment := range []string{"killed", "on", "killed", "on"} {
+		emitter.splitsChanged([]SplitChange{{Name: "feature", Current: &SplitView{Name: "feature", DefaultTreatment: treatment}}})
+	}
+
+	for start := time.Now(); time.Since(start) < 2*time.Second; time.Sleep(10 * time.Millisecond) {
+		mutex.Lock()
+		count := len(received)
+		mutex.Unlock()
+		if count == 4 {
+			break
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if strings.Join(received, ",") != "killed,on,killed,on" {
+		t.Error("Changes should be delivered in the order they were applied. Got: ", received)
+	}
+}
+
+func TestSnapshotStartup(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "snapshot")
+	defer os.RemoveAll(dir)
+
+	server := splittest.NewServer()
+	defer server.Close()
+	server.UpdateSegment("employees", []string{"key1"}, nil)
+	server.PutSplits(dtos.SplitDTO{
+		Name:             "feature",
+		ChangeNumber:     10,
+		TrafficTypeName:  "user",
+		DefaultTreatment: "off",
+		Conditions: []dtos.ConditionDTO{{
+			ConditionType: "WHITELIST",
+			MatcherGroup: dtos.MatcherGroupDTO{
+				Combiner: "AND",
+				Matchers: []dtos.MatcherDTO{{
+					MatcherType:        "IN_SEGMENT",
+					UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"},
+				}},
+			},
+			Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+		}},
+	})
+
+	cfg := conf.Default()
+	cfg.Advanced.StreamingEnabled = false
+	cfg.Snapshot.File = filepath.Join(dir, "splits.snapshot")
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("snapshot-writer", cfg)
+	if err := factory.BlockUntilReady(5); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); factory.SyncStatus()[SyncTaskSnapshot].LastSuccess.IsZero() && time.Since(start) < 3*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+	}
+	factory.Destroy()
+	contents, err := ioutil.ReadFile(cfg.Snapshot.File)
+	if err != nil {
+		t.Fatal("Snapshot should have been written", err)
+	}
+
+	// Split fetches made by the next instances hang, so only snapshots can make them ready
+	var hang int32 = 1
+	defer atomic.StoreInt32(&hang, 0)
+	cfg = conf.Default()
+	cfg.Advanced.StreamingEnabled = false
+	cfg.Advanced.HTTPInterceptors = []conf.HTTPInterceptor{
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			for req.URL.Path == splittest.SplitChangesPath && atomic.LoadInt32(&hang) == 1 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return next.RoundTrip(req)
+		},
+	}
+	cfg.Snapshot.Bootstrap = contents
+	server.Configure(cfg)
+	factory, _ = NewSplitFactory("snapshot-reader", cfg)
+	defer factory.Destroy()
+	if err = factory.BlockUntilReady(1); err != nil {
+		t.Fatal("Factory should be ready right away", err)
+	}
+	client := factory.Client()
+	if treatment := client.Treatment("key1", "feature", nil); treatment != "on" {
+		t.Error("Snapshot splits & segments should be evaluated. Got: ", treatment)
+	}
+
+	// Synchronization resumes from the snapshot change numbers
+	server.UpdateSegment("employees", []string{"key2"}, nil)
+	atomic.StoreInt32(&hang, 0)
+	if _, err = factory.SyncNow(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if treatment := client.Treatment("key2", "feature", nil); treatment != "on" {
+		t.Error("Changes applied after the snapshot should be fetched. Got: ", treatment)
+	}
+
+	atomic.StoreInt32(&hang, 1)
+	cfg.Snapshot.Bootstrap = []byte("{}")
+	invalid, _ := NewSplitFactory("snapshot-invalid", cfg)
+	defer invalid.Destroy()
+	if invalid.BlockUntilReady(1) == nil {
+		t.Error("Invalid snapshots should be ignored")
+	}
+}
+
+func TestSnapshotStartupFetchFailure(t *testing.T) {
+	server := splittest.NewServer()
+	defer server.Close()
+	server.PutSplits(dtos.SplitDTO{Name: "feature", ChangeNumber: 10, TrafficTypeName: "user", DefaultTreatment: "on"})
+	server.SetStatus(splittest.SplitChangesPath, http.StatusInternalServerError)
+
+	bootstrap, _ := json.Marshal(snapshot.Snapshot{
+		Version:    snapshot.Version,
+		SplitsTill: 5,
+		Splits: []dtos.SplitDTO{
+			{Name: "feature", ChangeNumber: 5, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "off"},
+		},
+	})
+
+	cfg := conf.Default()
+	cfg.Advanced.StreamingEnabled = false
+	cfg.Advanced.FetchInitAttempts = 1
+	cfg.Advanced.FetchBackoffBase = 10
+	cfg.TaskPeriods.SplitSync = 1
+	cfg.TaskPeriods.ImpressionSync = 1
+	cfg.Snapshot.Bootstrap = bootstrap
+	server.Configure(cfg)
+	factory, _ := NewSplitFactory("snapshot-fetch-failure", cfg)
+	defer factory.Destroy()
+	if err := factory.BlockUntilReady(1); err != nil {
+		t.Fatal("Factory should be ready right away", err)
+	}
+	client := factory.Client()
+	if treatment := client.Treatment("key1", "feature", nil); treatment != "off" {
+		t.Error("Snapshot splits should be evaluated. Got: ", treatment)
+	}
+	for start := time.Now(); factory.SyncStatus()[SyncTaskSplits].LastFailure.IsZero() && time.Since(start) < 3*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if factory.SyncStatus()[SyncTaskSplits].LastFailure.IsZero() {
+		t.Fatal("The initial split fetch should have failed")
+	}
+	if err := factory.InitializationError(); err != nil || !factory.IsReady() {
+		t.Error("A failed fetch should not fail the initialization of a snapshot factory", err)
+	}
+
+	// Polling keeps retrying & every recording task is running
+	server.SetStatus(splittest.SplitChangesPath, 0)
+	treatment := client.Treatment("key1", "feature", nil)
+	for start := time.Now(); treatment != "on" && time.Since(start) < 5*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+		treatment = client.Treatment("key1", "feature", nil)
+	}
+	if treatment != "on" {
+		t.Error("Splits should be fetched once the server recovers. Got: ", treatment)
+	}
+	for start := time.Now(); len(server.Impressions()) == 0 && time.Since(start) < 5*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(server.Impressions()) == 0 {
+		t.Error("Impressions should be posted")
+	}
+}
+
+func TestOfflineMode(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "offline")
+	defer os.RemoveAll(dir)
+
+	splitChanges, _ := json.Marshal(dtos.SplitChangesDTO{
+		Since: -1,
+		Till:  10,
+		Splits: []dtos.SplitDTO{
+			{
+				Name:             "feature",
+				ChangeNumber:     10,
+				Status:           "ACTIVE",
+				TrafficTypeName:  "user",
+				DefaultTreatment: "off",
+				Conditions: []dtos.ConditionDTO{{
+					ConditionType: "WHITELIST",
+					MatcherGroup: dtos.MatcherGroupDTO{
+						Combiner: "AND",
+						Matchers: []dtos.MatcherDTO{{
+							MatcherType:        "IN_SEGMENT",
+							UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"},
+						}},
+					},
+					Partitions: []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+				}},
+			},
+			{Name: "archived", ChangeNumber: 9, Status: "ARCHIVED", TrafficTypeName: "user", DefaultTreatment: "on"},
+		},
+	})
+	splitChangesFile := filepath.Join(dir, "splitChanges.json")
+	ioutil.WriteFile(splitChangesFile, splitChanges, 0644)
+
+	cfg := conf.Default()
+	cfg.OperationMode = "offline"
+	if _, err := NewSplitFactory("", cfg); err == nil {
+		t.Error("Offline mode should require split changes")
+	}
+
+	// Requests made to Split servers would fail
+	cfg.Advanced.HTTPInterceptors = []conf.HTTPInterceptor{
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			t.Error("No requests should be made in offline mode. Got: ", req.URL.String())
+			return next.RoundTrip(req)
+		},
+	}
+	cfg.Offline.SplitChangesFile = splitChangesFile
+	cfg.Offline.SegmentChanges = []io.Reader{strings.NewReader(
+		`{"name": "employees", "added": ["key1", "key2"], "removed": [], "since": -1, "till": 5}`,
+	)}
+	cfg.Offline.ImpressionsFile = filepath.Join(dir, "impressions.jsonl")
+	cfg.Offline.EventsFile = filepath.Join(dir, "events.jsonl")
+	factory, err := NewSplitFactory("", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !factory.IsReady() {
+		t.Error("Offline factories should be ready right away")
+	}
+
+	client := factory.Client()
+	if treatment := client.Treatment("key1", "feature", nil); treatment != "on" {
+		t.Error("Segment keys should match. Got: ", treatment)
+	}
+	if treatment := client.Treatment("other", "feature", nil); treatment != "off" {
+		t.Error("Default treatment should be returned. Got: ", treatment)
+	}
+	if treatment := client.Treatment("key1", "archived", nil); treatment != "control" {
+		t.Error("Archived splits should not be evaluated. Got: ", treatment)
+	}
+	if err = client.Track("key1", "user", "click", 1.0, nil); err != nil {
+		t.Error(err)
+	}
+	factory.Destroy()
+
+	impressionLines, _ := ioutil.ReadFile(cfg.Offline.ImpressionsFile)
+	var impression storage.Impression
+	lines := strings.Split(strings.TrimSpace(string(impressionLines)), "\n")
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &impression) != nil || impression.KeyName != "key1" || impression.Treatment != "on" {
+		t.Error("Impressions should be written as JSON lines", string(impressionLines))
+	}
+	eventLines, _ := ioutil.ReadFile(cfg.Offline.EventsFile)
+	var event dtos.EventDTO
+	if json.Unmarshal(eventLines, &event) != nil || event.EventTypeID != "click" || event.Value == nil {
+		t.Error("Events should be written as JSON lines", string(eventLines))
+	}
+}
@@ -0,0 +1,263 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/datastructures/set"
+)
+
+// Events emitted by the factory, to which callbacks are subscribed with SplitFactory.On
+const (
+	// SdkReady is emitted once splits & segments are synchronized and evaluations can be performed
+	SdkReady = "SDK_READY"
+	// SdkReadyTimedOut is emitted when the sdk is not ready after BlockUntilReady seconds. Synchronization continues
+	// in background, and SdkReady is emitted once it succeeds
+	SdkReadyTimedOut = "SDK_READY_TIMED_OUT"
+	// SdkUpdate is emitted every time split or segment changes are applied once the sdk is ready
+	SdkUpdate = "SDK_UPDATE"
+	// SdkDestroyed is emitted when the factory is destroyed
+	SdkDestroyed = "SDK_DESTROYED"
+)
+
+// SplitChange describes a change applied to a split by synchronization. Previous is nil for splits that were not
+// stored, and Current is nil for archived splits, which are removed
+type SplitChange struct {
+	Name     string
+	Previous *SplitView
+	Current  *SplitView
+}
+
+// splitChangeListener is called with the changes of the splits it's interested in, or all of them if splitNames
+// is nil
+type splitChangeListener struct {
+	splitNames map[string]bool
+	callback   func(change SplitChange)
+}
+
+// splitChangeBatch holds changes applied together, along with the listeners subscribed when they were applied
+type splitChangeBatch struct {
+	changes   []SplitChange
+	listeners []splitChangeListener
+}
+
+// eventEmitter calls the callbacks subscribed to each event in background, so that slow callbacks don't block
+// synchronization. Split changes are queued and delivered by a single goroutine, so that listeners get them in order
+type eventEmitter struct {
+	callbacks      map[string][]func()
+	splitListeners []splitChangeListener
+	pendingChanges []splitChangeBatch
+	delivering     bool
+	emitted        map[string]bool
+	mutex          sync.Mutex
+}
+
+func newEventEmitter() *eventEmitter {
+	return &eventEmitter{
+		callbacks: make(map[string][]func()),
+		emitted:   make(map[string]bool),
+	}
+}
+
+// on subscribes a callback. Callbacks subscribed to one-time events that were already emitted are called right away
+func (e *eventEmitter) on(event string, callback func()) error {
+	switch event {
+	case SdkReady, SdkReadyTimedOut, SdkUpdate, SdkDestroyed:
+	default:
+		return fmt.Errorf("Unknown event \"%s\"", event)
+	}
+	if callback == nil {
+		return fmt.Errorf("Callback for event \"%s\" must not be nil", event)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.callbacks[event] = append(e.callbacks[event], callback)
+	if e.emitted[event] && (event == SdkReady || event == SdkDestroyed) {
+		go callback()
+	}
+	return nil
+}
+
+// emit calls every callback subscribed to an event. Factories built without an emitter discard events
+func (e *eventEmitter) emit(event string) {
+	if e == nil {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.emitted[event] = true
+	for _, callback := range e.callbacks[event] {
+		go callback()
+	}
+}
+
+// onSplitChange subscribes a listener to the changes of the given splits, or every split if none is given
+func (e *eventEmitter) onSplitChange(callback func(change SplitChange), splitNames []string) error {
+	if callback == nil {
+		return errors.New("Split change listener must not be nil")
+	}
+	listener := splitChangeListener{callback: callback}
+	if len(splitNames) > 0 {
+		listener.splitNames = make(map[string]bool, len(splitNames))
+		for _, name := range splitNames {
+			listener.splitNames[name] = true
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.splitListeners = append(e.splitListeners, listener)
+	return nil
+}
+
+// updating returns true once the sdk is ready and until it's destroyed. Changes applied earlier are part of its
+// initialization, and are not notified
+func (e *eventEmitter) updating() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.emitted[SdkReady] && !e.emitted[SdkDestroyed]
+}
+
+// update emits SdkUpdate for changes applied while updating
+func (e *eventEmitter) update() {
+	if e.updating() {
+		e.emit(SdkUpdate)
+	}
+}
+
+// splitsChanged emits SdkUpdate and queues split changes applied while updating for the listeners interested in
+// them. Changes are delivered in order, in background
+func (e *eventEmitter) splitsChanged(changes []SplitChange) {
+	if !e.updating() {
+		return
+	}
+	e.emit(SdkUpdate)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if len(e.splitListeners) == 0 {
+		return
+	}
+	listeners := make([]splitChangeListener, len(e.splitListeners))
+	copy(listeners, e.splitListeners)
+	e.pendingChanges = append(e.pendingChanges, splitChangeBatch{changes: changes, listeners: listeners})
+	if !e.delivering {
+		e.delivering = true
+		go e.deliverSplitChanges()
+	}
+}
+
+// deliverSplitChanges calls listeners with the queued split changes until the queue is empty
+func (e *eventEmitter) deliverSplitChanges() {
+	for {
+		e.mutex.Lock()
+		if len(e.pendingChanges) == 0 {
+			e.delivering = false
+			e.mutex.Unlock()
+			return
+		}
+		batch := e.pendingChanges[0]
+		e.pendingChanges = e.pendingChanges[1:]
+		e.mutex.Unlock()
+
+		for _, change := range batch.changes {
+			for _, listener := range batch.listeners {
+				if listener.splitNames == nil || listener.splitNames[change.Name] {
+					listener.callback(change)
+				}
+			}
+		}
+	}
+}
+
+// notifyingSplitStorage wraps the split storage updated by synchronization, calling notify with the splits that
+// change
+type notifyingSplitStorage struct {
+	storage.SplitStorage
+	notify func(changes []SplitChange)
+}
+
+// PutMany stores splits, notifying those whose change number moved
+func (s *notifyingSplitStorage) PutMany(splits []dtos.SplitDTO, changeNumber int64) {
+	if len(splits) == 0 {
+		s.SplitStorage.PutMany(splits, changeNumber)
+		return
+	}
+
+	names := make([]string, 0, len(splits))
+	for _, split := range splits {
+		names = append(names, split.Name)
+	}
+	stored := s.SplitStorage.FetchMany(names)
+
+	changes := make([]SplitChange, 0, len(splits))
+	for index := range splits {
+		split := &splits[index]
+		previous := stored[split.Name]
+		if previous != nil && previous.ChangeNumber == split.ChangeNumber {
+			continue
+		}
+		change := SplitChange{Name: split.Name, Current: newSplitView(split)}
+		if previous != nil {
+			change.Previous = newSplitView(previous)
+		}
+		changes = append(changes, change)
+	}
+
+	s.SplitStorage.PutMany(splits, changeNumber)
+	if len(changes) > 0 {
+		s.notify(changes)
+	}
+}
+
+// Remove removes a split, notifying if it was stored
+func (s *notifyingSplitStorage) Remove(splitName string) {
+	previous := s.SplitStorage.Get(splitName)
+	s.SplitStorage.Remove(splitName)
+	if previous != nil {
+		s.notify([]SplitChange{{Name: splitName, Previous: newSplitView(previous)}})
+	}
+}
+
+// notifyingSegmentStorage wraps the segment storage updated by synchronization, calling notify whenever a segment
+// changes
+type notifyingSegmentStorage struct {
+	storage.SegmentStorage
+	notify func()
+}
+
+// Put stores a segment, notifying if its change number moved
+func (s *notifyingSegmentStorage) Put(name string, segment *set.ThreadUnsafeSet, changeNumber int64) {
+	previous := s.SegmentStorage.Till(name)
+	s.SegmentStorage.Put(name, segment, changeNumber)
+	if changeNumber != previous {
+		s.notify()
+	}
+}
+
+// Remove removes a segment, notifying if it was stored
+func (s *notifyingSegmentStorage) Remove(segmentName string) {
+	existed := s.SegmentStorage.Get(segmentName) != nil
+	s.SegmentStorage.Remove(segmentName)
+	if existed {
+		s.notify()
+	}
+}
+
+// On subscribes a callback to one of the events emitted by the factory: SdkReady, SdkReadyTimedOut, SdkUpdate or
+// SdkDestroyed. Callbacks are called in their own goroutine. Those subscribed to SdkReady or SdkDestroyed after the
+// event was emitted are called right away
+func (f *SplitFactory) On(event string, callback func()) error {
+	return f.emitter.on(event, callback)
+}
+
+// OnSplitChange subscribes a listener to the changes applied to the given splits, or to every split if none is
+// given. It's called with the previous & current definitions of each split added, modified (including kills and
+// default treatment & config changes) or archived once the sdk is ready. Changes are delivered in order, in background
+func (f *SplitFactory) OnSplitChange(listener func(change SplitChange), splitNames ...string) error {
+	return f.emitter.onSplitChange(listener, splitNames)
+}
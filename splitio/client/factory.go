@@ -3,8 +3,12 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,12 +18,17 @@ import (
 	"github.com/emccrckn/go-client/splitio/engine"
 	"github.com/emccrckn/go-client/splitio/engine/evaluator"
 	impressionlistener "github.com/emccrckn/go-client/splitio/impressionListener"
+	"github.com/emccrckn/go-client/splitio/impressions"
+	"github.com/emccrckn/go-client/splitio/push"
+	"github.com/emccrckn/go-client/splitio/service"
 	"github.com/emccrckn/go-client/splitio/service/api"
 	"github.com/emccrckn/go-client/splitio/service/local"
 	"github.com/emccrckn/go-client/splitio/storage"
 	"github.com/emccrckn/go-client/splitio/storage/mutexmap"
 	"github.com/emccrckn/go-client/splitio/storage/mutexqueue"
 	"github.com/emccrckn/go-client/splitio/storage/redisdb"
+	"github.com/emccrckn/go-client/splitio/storage/snapshot"
+	"github.com/emccrckn/go-client/splitio/storage/spool"
 	"github.com/emccrckn/go-client/splitio/tasks"
 	"github.com/emccrckn/go-toolkit/asynctask"
 	"github.com/emccrckn/go-toolkit/logging"
@@ -29,26 +38,33 @@ const (
 	sdkStatusDestroyed = iota
 	sdkStatusInitializing
 	sdkStatusReady
+	sdkStatusAuthFailed
 
 	sdkInitializationFailed = -1
+	sdkAuthenticationFailed = -2
 )
 
 type sdkStorages struct {
-	splits      storage.SplitStorageConsumer
-	segments    storage.SegmentStorageConsumer
-	impressions storage.ImpressionStorageProducer
-	events      storage.EventStorageProducer
-	telemetry   storage.MetricsStorageProducer
+	splits           storage.SplitStorageConsumer
+	segments         storage.SegmentStorageConsumer
+	impressions      storage.ImpressionStorageProducer
+	impressionsCount storage.ImpressionsCountStorageProducer
+	events           storage.EventStorageProducer
+	telemetry        storage.MetricsStorageProducer
+	closers          []io.Closer
 }
 
 type sdkSync struct {
-	splits      *asynctask.AsyncTask
-	segments    *asynctask.AsyncTask
-	impressions *asynctask.AsyncTask
-	gauges      *asynctask.AsyncTask
-	counters    *asynctask.AsyncTask
-	latencies   *asynctask.AsyncTask
-	events      *asynctask.AsyncTask
+	splits           *asynctask.AsyncTask
+	segments         *asynctask.AsyncTask
+	impressions      *asynctask.AsyncTask
+	gauges           *asynctask.AsyncTask
+	counters         *asynctask.AsyncTask
+	latencies        *asynctask.AsyncTask
+	events           *asynctask.AsyncTask
+	impressionsCount *asynctask.AsyncTask
+	snapshot         *asynctask.AsyncTask
+	statuses         map[string]*tasks.TaskStatus
 }
 
 // SplitFactory struct is responsible for instantiating and storing instances of client and manager.
@@ -63,23 +79,33 @@ type SplitFactory struct {
 	mutex                 sync.Mutex
 	cfg                   *conf.SplitSdkConfig
 	impressionListener    *impressionlistener.WrapperImpressionListener
+	impressionObserver    *impressions.Observer
+	pushManager           *push.Manager
+	initializationError   error
+	authError             *AuthError
+	credentials           *credentials
+	validationCfg         conf.AdvancedConfig
+	manualSync            *manualSync
+	emitter               *eventEmitter
 	logger                logging.LoggerInterface
 }
 
 // Client returns the split client instantiated by the factory
 func (f *SplitFactory) Client() *SplitClient {
 	return &SplitClient{
-		logger:      f.logger,
-		evaluator:   evaluator.NewEvaluator(f.storages.splits, f.storages.segments, engine.NewEngine(f.logger), f.logger),
-		impressions: f.storages.impressions,
-		metrics:     f.storages.telemetry,
-		events:      f.storages.events,
+		logger:           f.logger,
+		evaluator:        evaluator.NewEvaluator(f.storages.splits, f.storages.segments, engine.NewEngine(f.logger), f.logger),
+		impressions:      f.storages.impressions,
+		impressionsCount: f.storages.impressionsCount,
+		metrics:          f.storages.telemetry,
+		events:           f.storages.events,
 		validator: inputValidation{
 			logger:       f.logger,
 			splitStorage: f.storages.splits,
 		},
 		factory:            f,
 		impressionListener: f.impressionListener,
+		impressionObserver: f.impressionObserver,
 	}
 }
 
@@ -104,15 +130,40 @@ func (f *SplitFactory) IsReady() bool {
 }
 
 // initializates task for localhost mode
-func (f *SplitFactory) initializationLocalhost(readyChannel chan string, syncTasks *sdkSync) {
+func (f *SplitFactory) initializationLocalhost(
+	readyChannel chan string,
+	syncTasks *sdkSync,
+	splitFetcher *local.FileSplitFetcher,
+) {
 	syncTasks.splits.Start()
 
-	<-readyChannel
+	msg := <-readyChannel
+	if msg == "SPLITS_ERROR" {
+		err := splitFetcher.LastError()
+		f.logger.Error("Localhost mode: split file could not be loaded: ", err)
+		f.failInitialization(err)
+		return
+	}
+	if syncTasks.segments != nil {
+		// JSON split files may reference segments, which are read from their own files
+		syncTasks.segments.Start()
+		<-readyChannel
+	}
 	f.broadcastReadiness(sdkStatusReady)
 }
 
-// initializates tasks for in-memory mode
-func (f *SplitFactory) initializationInMemory(readyChannel chan string, syncTasks *sdkSync) {
+// initializates tasks for in-memory mode. When starting from a snapshot, the sdk is ready right away and the tasks
+// fetch the changes applied since it was taken
+func (f *SplitFactory) initializationInMemory(
+	readyChannel chan string,
+	streamingStatus chan string,
+	syncTasks *sdkSync,
+	fromSnapshot bool,
+) {
+	if fromSnapshot {
+		f.broadcastReadiness(sdkStatusReady)
+	}
+
 	// Start split fetching task
 	syncTasks.splits.Start()
 
@@ -123,13 +174,28 @@ func (f *SplitFactory) initializationInMemory(readyChannel chan string, syncTask
 		syncTasks.segments.Start()
 		break
 	case "SPLITS_ERROR":
-		// Broadcast on error
-		f.broadcastReadiness(sdkInitializationFailed)
-		return
+		if f.AuthenticationError() != nil {
+			return
+		}
+		if !fromSnapshot {
+			// Broadcast on error
+			f.broadcastReadiness(sdkInitializationFailed)
+			return
+		}
+		// The sdk is already ready, so snapshot definitions keep being served while polling retries
+		f.logger.Error("Splits could not be fetched, snapshot definitions will be used until polling succeeds")
+		syncTasks.segments.Start()
 	}
 
 	msg = <-readyChannel
+	if f.AuthenticationError() != nil {
+		return
+	}
 	switch msg {
+	case "SEGMENTS_ERROR":
+		// Only reported when starting from a snapshot, segment workers keep retrying
+		f.logger.Error("Segments could not be fetched, snapshot definitions will be used until polling succeeds")
+		fallthrough
 	case "SEGMENTS_READY":
 		// Once segments are ready, start impressions and metrics recording tasks
 		syncTasks.impressions.Start()
@@ -137,8 +203,48 @@ func (f *SplitFactory) initializationInMemory(readyChannel chan string, syncTask
 		syncTasks.counters.Start()
 		syncTasks.gauges.Start()
 		syncTasks.events.Start()
+		if syncTasks.impressionsCount != nil {
+			syncTasks.impressionsCount.Start()
+		}
+		if syncTasks.snapshot != nil {
+			syncTasks.snapshot.Start()
+		}
 		// Broadcast ready status for SDK
 		f.broadcastReadiness(sdkStatusReady)
+
+		if f.pushManager != nil {
+			f.pushManager.Start()
+			go f.handleStreamingStatus(streamingStatus, readyChannel, syncTasks)
+		}
+	}
+}
+
+// handleStreamingStatus pauses split & segment polling while the streaming connection is healthy
+// and resumes it whenever the connection is lost
+func (f *SplitFactory) handleStreamingStatus(streamingStatus chan string, readyChannel chan string, syncTasks *sdkSync) {
+	for {
+		select {
+		case status, ok := <-streamingStatus:
+			if !ok {
+				return
+			}
+			switch status {
+			case push.StreamingReady:
+				f.logger.Info("Streaming connected, pausing split & segment polling")
+				syncTasks.splits.Stop()
+				syncTasks.segments.Stop()
+			case push.StreamingDown:
+				if f.IsDestroyed() || f.AuthenticationError() != nil {
+					return
+				}
+				f.logger.Info("Streaming disconnected, resuming split & segment polling")
+				syncTasks.splits.Start()
+				syncTasks.segments.Start()
+			}
+		case <-readyChannel:
+			// Polling tasks report readiness again every time they are restarted. Nothing to do here,
+			// the SDK is already ready.
+		}
 	}
 }
 
@@ -163,16 +269,103 @@ func dataFlusher(syncTasks *sdkSync, inMememoryFullQueue chan string, logger log
 	}
 }
 
-// broadcastReadiness broadcasts message to all the subscriptors
+// broadcastReadiness broadcasts message to all the subscriptors. Sends never block, since subscriptors only wait
+// for the first message. Initialization failures caused by an authentication failure are not broadcast, subscriptors
+// were already told
 func (f *SplitFactory) broadcastReadiness(status int) {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
+	if status == sdkInitializationFailed && f.authError != nil {
+		return
+	}
 	if f.status.Load() == sdkStatusInitializing && status == sdkStatusReady {
 		f.status.Store(sdkStatusReady)
+		f.emitter.emit(SdkReady)
 	}
 	for _, subscriptor := range f.readinessSubscriptors {
-		subscriptor <- status
+		select {
+		case subscriptor <- status:
+		default:
+		}
+	}
+}
+
+// failInitialization records why the sdk could not be initialized and lets subscriptors know
+func (f *SplitFactory) failInitialization(err error) {
+	f.mutex.Lock()
+	f.initializationError = err
+	f.mutex.Unlock()
+	f.broadcastReadiness(sdkInitializationFailed)
+}
+
+// failAuthentication moves the sdk into its terminal authentication failed status, stopping synchronization and
+// letting subscriptors know
+func (f *SplitFactory) failAuthentication(err *AuthError) {
+	f.mutex.Lock()
+	if f.authError != nil || f.IsDestroyed() {
+		f.mutex.Unlock()
+		return
+	}
+	f.authError = err
+	f.status.Store(sdkStatusAuthFailed)
+	f.mutex.Unlock()
+
+	f.logger.Error(err.Error(), ". Stopping synchronization")
+	// Failures are detected while tasks are running, so they are stopped in background
+	go f.stopSync()
+	f.broadcastReadiness(sdkAuthenticationFailed)
+}
+
+// AuthenticationError returns the reason why Split servers rejected the apikey, or nil if they didn't
+func (f *SplitFactory) AuthenticationError() *AuthError {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.authError
+}
+
+// RotateAPIKey replaces the apikey sent to Split servers by every fetcher & recorder, keeping storages and queued
+// data. Streaming connections switch to the new apikey the next time they connect. Apikeys rejected by Split
+// servers are reported as a *dtos.HTTPError, and the current one is kept
+func (f *SplitFactory) RotateAPIKey(apikey string) error {
+	if apikey == "" {
+		return errors.New("API key rotation: apikey must be a non-empty string")
 	}
+	if f.IsDestroyed() {
+		return errors.New("API key rotation: Client is destroyed")
+	}
+	if f.credentials == nil {
+		return fmt.Errorf("API key rotation: not supported in %s mode", f.operationMode)
+	}
+	if f.credentials.provider != nil {
+		return errors.New("API key rotation: apikey is supplied by Advanced.APIKeyProvider")
+	}
+	if f.AuthenticationError() != nil {
+		return errors.New("API key rotation: authentication already failed, a new factory is needed")
+	}
+
+	// Apikeys rejected with 401 are refused too, since the first request sent with them would stop the factory
+	if err := api.ValidateRotatedApikey(apikey, f.validationCfg); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	previous := f.apikey
+	f.apikey = apikey
+	f.credentials.set(apikey)
+	f.mutex.Unlock()
+
+	removeInstanceFromTracker(previous)
+	setFactory(apikey, f.logger)
+	f.logger.Info("API key rotated to ", logging.ObfuscateAPIKey(apikey))
+	return nil
+}
+
+// InitializationError returns the reason why the sdk failed to initialize, or nil if it didn't fail or the reason
+// is unknown. In localhost mode, an invalid split file is reported through a *local.ValidationReport
+func (f *SplitFactory) InitializationError() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.initializationError
 }
 
 // subscribes listener
@@ -200,9 +393,33 @@ func (f *SplitFactory) BlockUntilReady(timer int) error {
 	if timer <= 0 {
 		return errors.New("SDK Initialization: timer must be positive number")
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(timer))
+	defer cancel()
+
+	err := f.blockUntilReady(ctx)
+	if err == context.DeadlineExceeded {
+		return fmt.Errorf("SDK Initialization: time of %d exceeded", timer)
+	}
+	return err
+}
+
+// BlockUntilReadyCtx blocks client or manager until the SDK is ready, error occurs or ctx is done. In the latter
+// case, the context's error is returned
+func (f *SplitFactory) BlockUntilReadyCtx(ctx context.Context) error {
+	if f.IsReady() {
+		return nil
+	}
+	return f.blockUntilReady(ctx)
+}
+
+func (f *SplitFactory) blockUntilReady(ctx context.Context) error {
 	if f.IsDestroyed() {
 		return errors.New("SDK Initialization: Client is destroyed")
 	}
+	if err := f.AuthenticationError(); err != nil {
+		return err
+	}
 	block := make(chan int, 1)
 
 	f.mutex.Lock()
@@ -219,16 +436,32 @@ func (f *SplitFactory) BlockUntilReady(timer int) error {
 
 	f.subscribe(subscriptorName, block)
 
+	// A failure recorded before subscribing won't be broadcasted again
+	if err := f.AuthenticationError(); err != nil {
+		return err
+	}
+	if err := f.InitializationError(); err != nil {
+		return fmt.Errorf("SDK Initialization failed: %s", err.Error())
+	}
+
 	select {
 	case status := <-block:
 		switch status {
 		case sdkStatusReady:
 			break
+		case sdkAuthenticationFailed:
+			return f.AuthenticationError()
 		case sdkInitializationFailed:
+			if err := f.AuthenticationError(); err != nil {
+				return err
+			}
+			if err := f.InitializationError(); err != nil {
+				return fmt.Errorf("SDK Initialization failed: %s", err.Error())
+			}
 			return errors.New("SDK Initialization failed")
 		}
-	case <-time.After(time.Second * time.Duration(timer)):
-		return fmt.Errorf("SDK Initialization: time of %d exceeded", timer)
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
 	return nil
@@ -236,14 +469,68 @@ func (f *SplitFactory) BlockUntilReady(timer int) error {
 
 // Destroy stops all async tasks and clears all storages
 func (f *SplitFactory) Destroy() {
-	if !f.IsDestroyed() {
-		removeInstanceFromTracker(f.apikey)
+	destroyed := f.IsDestroyed()
+	if !destroyed {
+		f.mutex.Lock()
+		apikey := f.apikey
+		f.mutex.Unlock()
+		removeInstanceFromTracker(apikey)
 	}
 	f.status.Store(sdkStatusDestroyed)
 
-	if f.cfg.OperationMode == "redis-consumer" {
+	if f.cfg.OperationMode != "redis-consumer" {
+		f.stopSync()
+
+		// Recording tasks close their spools once the final flush is done. Spools of tasks that weren't running
+		// are closed right away
+		closeIdleSpool(f.tasks.impressions, f.storages.impressions)
+		closeIdleSpool(f.tasks.events, f.storages.events)
+	}
+
+	if !destroyed {
+		for _, closer := range f.storages.closers {
+			closer.Close()
+		}
+		f.emitter.emit(SdkDestroyed)
+	}
+}
+
+// closeIdleSpool closes a spooled storage unless the task consuming it is running, in which case the task closes
+// it when stopped
+func closeIdleSpool(task *asynctask.AsyncTask, spooled interface{}) {
+	var closer io.Closer
+	switch s := spooled.(type) {
+	case storage.ImpressionSpoolConsumer:
+		closer = s
+	case storage.EventSpoolConsumer:
+		closer = s
+	default:
 		return
 	}
+	if task == nil || !task.IsRunning() {
+		closer.Close()
+	}
+}
+
+// watchReadyTimeout emits SdkReadyTimedOut if the sdk is still initializing once the timeout elapses. Synchronization
+// is not interrupted
+func (f *SplitFactory) watchReadyTimeout(timeout time.Duration) {
+	time.AfterFunc(timeout, func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		if f.status.Load() == sdkStatusInitializing {
+			f.logger.Warning(fmt.Sprintf("SDK not ready after %v, synchronization continues in background", timeout))
+			f.emitter.emit(SdkReadyTimedOut)
+		}
+	})
+}
+
+// stopSync stops streaming & every synchronization task
+func (f *SplitFactory) stopSync() {
+	// Stop streaming before polling tasks, so that it cannot resume them
+	if f.pushManager != nil {
+		f.pushManager.Stop()
+	}
 
 	// Stop all tasks
 	if f.tasks.splits != nil {
@@ -265,6 +552,15 @@ func (f *SplitFactory) Destroy() {
 	if f.tasks.latencies != nil {
 		f.tasks.latencies.Stop()
 	}
+	if f.tasks.events != nil {
+		f.tasks.events.Stop()
+	}
+	if f.tasks.impressionsCount != nil {
+		f.tasks.impressionsCount.Stop()
+	}
+	if f.tasks.snapshot != nil {
+		f.tasks.snapshot.Stop()
+	}
 }
 
 // setupLogger sets up the logger according to the parameters submitted by the sdk user
@@ -290,6 +586,13 @@ func setupInMemoryFactory(
 		return nil, err
 	}
 
+	// Requests are sent with the current apikey, and those rejected because of it move the factory into its
+	// authentication failed status
+	validationCfg := cfg.Advanced
+	credentials := newCredentials(apikey)
+	watcher := &authWatcher{}
+	cfg = watcher.watch(credentials.provide(cfg))
+
 	inMememoryFullQueue := make(chan string, 2) // Size 2: So that it's able to accept one event from each resource simultaneously.
 
 	storages := sdkStorages{
@@ -300,25 +603,74 @@ func setupInMemoryFactory(
 		events:      mutexqueue.NewMQEventsStorage(cfg.Advanced.EventsQueueSize, inMememoryFullQueue, logger),
 	}
 
+	if cfg.Spool.Directory != "" {
+		impressionsSpool, err := spool.Open(filepath.Join(cfg.Spool.Directory, "impressions"), &cfg.Spool)
+		if err != nil {
+			return nil, err
+		}
+		eventsSpool, err := spool.Open(filepath.Join(cfg.Spool.Directory, "events"), &cfg.Spool)
+		if err != nil {
+			impressionsSpool.Close()
+			return nil, err
+		}
+		storages.impressions = spool.NewImpressionsStorage(impressionsSpool, logger)
+		storages.events = spool.NewEventsStorage(eventsSpool, logger)
+	}
+
+	if cfg.ImpressionsMode == conf.ImpressionsModeCount {
+		storages.impressionsCount = mutexmap.NewMMImpressionsCountStorage()
+	}
+
+	// Splits & segments of a snapshot are served right away, and synchronization resumes from their change numbers
+	fromSnapshot := restoreSnapshot(
+		&cfg.Snapshot,
+		storages.splits.(storage.SplitStorage),
+		storages.segments.(storage.SegmentStorage),
+		logger,
+	)
+
+	// Changes applied by synchronization are notified through SDK_UPDATE & split change listeners
+	emitter := newEventEmitter()
+	notifyingSplits := &notifyingSplitStorage{SplitStorage: storages.splits.(storage.SplitStorage), notify: emitter.splitsChanged}
+	notifyingSegments := &notifyingSegmentStorage{SegmentStorage: storages.segments.(storage.SegmentStorage), notify: emitter.update}
+
 	readyChannel := make(chan string, 1)
 
+	statuses := newTaskStatuses(
+		SyncTaskSplits,
+		SyncTaskSegments,
+		SyncTaskImpressions,
+		SyncTaskCounters,
+		SyncTaskGauges,
+		SyncTaskLatencies,
+		SyncTaskEvents,
+	)
+	syncLock := tasks.NewSyncLock()
+	splitFetcher := api.NewHTTPSplitFetcher(apikey, cfg, logger)
+	segmentFetcher := api.NewHTTPSegmentFetcher(apikey, cfg, logger)
 	syncTasks := sdkSync{
 		splits: tasks.NewFetchSplitsTask(
-			storages.splits.(storage.SplitStorage),
-			api.NewHTTPSplitFetcher(apikey, cfg, logger),
+			notifyingSplits,
+			splitFetcher,
 			cfg.TaskPeriods.SplitSync,
 			logger,
 			readyChannel,
+			fromSnapshot,
+			syncLock,
+			statuses[SyncTaskSplits],
 		),
 		segments: tasks.NewFetchSegmentsTask(
-			storages.splits.(storage.SplitStorage),
-			storages.segments.(storage.SegmentStorage),
-			api.NewHTTPSegmentFetcher(apikey, cfg, logger),
+			notifyingSplits,
+			notifyingSegments,
+			segmentFetcher,
 			cfg.TaskPeriods.SegmentSync,
 			cfg.Advanced.SegmentWorkers,
 			cfg.Advanced.SegmentQueueSize,
 			logger,
 			readyChannel,
+			fromSnapshot,
+			syncLock,
+			statuses[SyncTaskSegments],
 		),
 		impressions: tasks.NewRecordImpressionsTask(
 			storages.impressions.(storage.ImpressionStorage),
@@ -326,24 +678,28 @@ func setupInMemoryFactory(
 			cfg.TaskPeriods.ImpressionSync,
 			logger,
 			cfg.Advanced.ImpressionsBulkSize,
+			statuses[SyncTaskImpressions],
 		),
 		counters: tasks.NewRecordCountersTask(
 			storages.telemetry.(storage.MetricsStorage),
 			api.NewHTTPMetricsRecorder(apikey, cfg, metadata, logger),
 			cfg.TaskPeriods.CounterSync,
 			logger,
+			statuses[SyncTaskCounters],
 		),
 		gauges: tasks.NewRecordGaugesTask(
 			storages.telemetry.(storage.MetricsStorage),
 			api.NewHTTPMetricsRecorder(apikey, cfg, metadata, logger),
 			cfg.TaskPeriods.GaugeSync,
 			logger,
+			statuses[SyncTaskGauges],
 		),
 		latencies: tasks.NewRecordLatenciesTask(
 			storages.telemetry.(storage.MetricsStorage),
 			api.NewHTTPMetricsRecorder(apikey, cfg, metadata, logger),
 			cfg.TaskPeriods.LatencySync,
 			logger,
+			statuses[SyncTaskLatencies],
 		),
 		events: tasks.NewRecordEventsTask(
 			storages.events.(storage.EventsStorage),
@@ -351,27 +707,116 @@ func setupInMemoryFactory(
 			cfg.Advanced.EventsBulkSize,
 			cfg.TaskPeriods.EventsSync,
 			logger,
+			statuses[SyncTaskEvents],
 		),
+		statuses: statuses,
+	}
+
+	if storages.impressionsCount != nil {
+		statuses[SyncTaskImpressionsCount] = tasks.NewTaskStatus()
+		syncTasks.impressionsCount = tasks.NewRecordImpressionsCountTask(
+			storages.impressionsCount.(storage.ImpressionsCountStorage),
+			api.NewHTTPImpressionsCountRecorder(apikey, cfg, metadata, logger),
+			cfg.TaskPeriods.ImpressionsCountSync,
+			logger,
+			statuses[SyncTaskImpressionsCount],
+		)
+	}
+
+	if cfg.Snapshot.File != "" {
+		statuses[SyncTaskSnapshot] = tasks.NewTaskStatus()
+		syncTasks.snapshot = tasks.NewWriteSnapshotTask(
+			storages.splits.(storage.SplitStorage),
+			storages.segments.(storage.SegmentStorage),
+			cfg.Snapshot.File,
+			cfg.Snapshot.Period,
+			logger,
+			statuses[SyncTaskSnapshot],
+		)
 	}
 
 	splitFactory := SplitFactory{
-		apikey:                apikey,
-		cfg:                   cfg,
-		metadata:              *metadata,
-		logger:                logger,
-		operationMode:         "inmemory-standalone",
-		storages:              storages,
-		tasks:                 syncTasks,
+		apikey:        apikey,
+		credentials:   credentials,
+		validationCfg: validationCfg,
+		cfg:           cfg,
+		metadata:      *metadata,
+		logger:        logger,
+		operationMode: "inmemory-standalone",
+		storages:      storages,
+		tasks:         syncTasks,
+		emitter:       emitter,
+		manualSync: &manualSync{
+			splitStorage:   notifyingSplits,
+			segmentStorage: notifyingSegments,
+			splitFetcher:   splitFetcher,
+			segmentFetcher: segmentFetcher,
+			syncLock:       syncLock,
+			statuses:       statuses,
+		},
 		readinessSubscriptors: make(map[int]chan int),
 	}
 	splitFactory.status.Store(sdkStatusInitializing)
+	watcher.attach(&splitFactory)
 
-	go splitFactory.initializationInMemory(readyChannel, &syncTasks)
+	var streamingStatus chan string
+	if cfg.Advanced.StreamingEnabled {
+		streamingStatus = make(chan string, 1)
+		splitFactory.pushManager = push.NewManager(
+			apikey,
+			cfg,
+			metadata,
+			notifyingSplits,
+			notifyingSegments,
+			api.NewHTTPSplitFetcher(apikey, cfg, logger),
+			api.NewHTTPSegmentFetcher(apikey, cfg, logger),
+			streamingStatus,
+			logger,
+		)
+	}
+
+	go splitFactory.initializationInMemory(readyChannel, streamingStatus, &syncTasks, fromSnapshot)
 	go dataFlusher(&syncTasks, inMememoryFullQueue, logger)
 
 	return &splitFactory, nil
 }
 
+// restoreSnapshot puts the splits & segments of the bootstrap snapshot, or the one stored in the snapshot file, in
+// the storages. Returns false if there is no snapshot to start from
+func restoreSnapshot(
+	cfg *conf.SnapshotConfig,
+	splitStorage storage.SplitStorage,
+	segmentStorage storage.SegmentStorage,
+	logger logging.LoggerInterface,
+) bool {
+	var stored *snapshot.Snapshot
+	var err error
+	switch {
+	case cfg.Bootstrap != nil:
+		stored, err = snapshot.Parse(cfg.Bootstrap)
+	case cfg.File != "":
+		stored, err = snapshot.Read(cfg.File)
+		if os.IsNotExist(err) {
+			return false
+		}
+	default:
+		return false
+	}
+	if err != nil {
+		logger.Error("Snapshot could not be restored, splits & segments will be fetched from scratch: ", err)
+		return false
+	}
+
+	stored.Restore(splitStorage, segmentStorage)
+	logger.Info(fmt.Sprintf(
+		"Restored snapshot of %d splits & %d segments with change number %d",
+		len(stored.Splits),
+		len(stored.Segments),
+		stored.SplitsTill,
+	))
+	return true
+}
+
 func setupRedisFactory(
 	apikey string,
 	cfg *conf.SplitSdkConfig,
@@ -392,6 +837,10 @@ func setupRedisFactory(
 		events:      redisdb.NewRedisEventsStorage(redisClient, metadata, logger),
 	}
 
+	if cfg.ImpressionsMode == conf.ImpressionsModeCount {
+		storages.impressionsCount = redisdb.NewRedisImpressionsCountStorage(redisClient, logger)
+	}
+
 	factory := &SplitFactory{
 		apikey:                apikey,
 		cfg:                   cfg,
@@ -399,12 +848,180 @@ func setupRedisFactory(
 		logger:                logger,
 		operationMode:         "redis-consumer",
 		storages:              storages,
+		emitter:               newEventEmitter(),
 		readinessSubscriptors: make(map[int]chan int),
 	}
 	factory.status.Store(sdkStatusReady)
+	factory.emitter.emit(SdkReady)
 	return factory, nil
 }
 
+func setupRedisStandaloneFactory(
+	apikey string,
+	cfg *conf.SplitSdkConfig,
+	logger logging.LoggerInterface,
+	metadata *splitio.SdkMetadata,
+) (*SplitFactory, error) {
+	err := api.ValidateApikey(apikey, cfg.Advanced)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests are sent with the current apikey, and those rejected because of it move the factory into its
+	// authentication failed status
+	validationCfg := cfg.Advanced
+	credentials := newCredentials(apikey)
+	watcher := &authWatcher{}
+	cfg = watcher.watch(credentials.provide(cfg))
+
+	redisClient, err := redisdb.NewPrefixedRedisClient(&cfg.Redis)
+	if err != nil {
+		logger.Error("Failed to instantiate redis client.")
+		return nil, err
+	}
+
+	splitStorage := redisdb.NewRedisSplitStorage(redisClient, logger)
+	segmentStorage := redisdb.NewRedisSegmentStorage(redisClient, logger)
+	impressionStorage := redisdb.NewRedisImpressionStorage(redisClient, metadata, logger)
+	metricsStorage := redisdb.NewRedisMetricsStorage(redisClient, metadata, logger)
+	eventStorage := redisdb.NewRedisEventsStorage(redisClient, metadata, logger)
+	impressionsCountStorage := redisdb.NewRedisImpressionsCountStorage(redisClient, logger)
+
+	storages := sdkStorages{
+		splits:      splitStorage,
+		segments:    segmentStorage,
+		impressions: impressionStorage,
+		telemetry:   metricsStorage,
+		events:      eventStorage,
+	}
+
+	if cfg.ImpressionsMode == conf.ImpressionsModeCount {
+		storages.impressionsCount = impressionsCountStorage
+	}
+
+	// Changes applied by synchronization are notified through SDK_UPDATE & split change listeners
+	emitter := newEventEmitter()
+	notifyingSplits := &notifyingSplitStorage{SplitStorage: splitStorage, notify: emitter.splitsChanged}
+	notifyingSegments := &notifyingSegmentStorage{SegmentStorage: segmentStorage, notify: emitter.update}
+
+	readyChannel := make(chan string, 1)
+
+	// Impressions & events queues are shared with every redis-consumer instance pointing to the same redis,
+	// so they are posted on behalf of the instance that generated them
+	statuses := newTaskStatuses(
+		SyncTaskSplits,
+		SyncTaskSegments,
+		SyncTaskImpressions,
+		SyncTaskCounters,
+		SyncTaskGauges,
+		SyncTaskLatencies,
+		SyncTaskEvents,
+		SyncTaskImpressionsCount,
+	)
+	syncLock := tasks.NewSyncLock()
+	splitFetcher := api.NewHTTPSplitFetcher(apikey, cfg, logger)
+	segmentFetcher := api.NewHTTPSegmentFetcher(apikey, cfg, logger)
+	syncTasks := sdkSync{
+		splits: tasks.NewFetchSplitsTask(
+			notifyingSplits,
+			splitFetcher,
+			cfg.TaskPeriods.SplitSync,
+			logger,
+			readyChannel,
+			false,
+			syncLock,
+			statuses[SyncTaskSplits],
+		),
+		segments: tasks.NewFetchSegmentsTask(
+			notifyingSplits,
+			notifyingSegments,
+			segmentFetcher,
+			cfg.TaskPeriods.SegmentSync,
+			cfg.Advanced.SegmentWorkers,
+			cfg.Advanced.SegmentQueueSize,
+			logger,
+			readyChannel,
+			false,
+			syncLock,
+			statuses[SyncTaskSegments],
+		),
+		impressions: tasks.NewRecordQueuedImpressionsTask(
+			impressionStorage,
+			api.NewHTTPImpressionRecorder(apikey, cfg, metadata, logger),
+			cfg.TaskPeriods.ImpressionSync,
+			logger,
+			cfg.Advanced.ImpressionsBulkSize,
+			statuses[SyncTaskImpressions],
+		),
+		counters: tasks.NewRecordCountersTask(
+			metricsStorage,
+			api.NewHTTPMetricsRecorder(apikey, cfg, metadata, logger),
+			cfg.TaskPeriods.CounterSync,
+			logger,
+			statuses[SyncTaskCounters],
+		),
+		gauges: tasks.NewRecordGaugesTask(
+			metricsStorage,
+			api.NewHTTPMetricsRecorder(apikey, cfg, metadata, logger),
+			cfg.TaskPeriods.GaugeSync,
+			logger,
+			statuses[SyncTaskGauges],
+		),
+		latencies: tasks.NewRecordLatenciesTask(
+			metricsStorage,
+			api.NewHTTPMetricsRecorder(apikey, cfg, metadata, logger),
+			cfg.TaskPeriods.LatencySync,
+			logger,
+			statuses[SyncTaskLatencies],
+		),
+		events: tasks.NewRecordQueuedEventsTask(
+			eventStorage,
+			api.NewHTTPEventsRecorder(apikey, cfg, metadata, logger),
+			cfg.Advanced.EventsBulkSize,
+			cfg.TaskPeriods.EventsSync,
+			logger,
+			statuses[SyncTaskEvents],
+		),
+		// Counts stored by redis-consumer instances in count mode are posted regardless of this instance's mode
+		impressionsCount: tasks.NewRecordImpressionsCountTask(
+			impressionsCountStorage,
+			api.NewHTTPImpressionsCountRecorder(apikey, cfg, metadata, logger),
+			cfg.TaskPeriods.ImpressionsCountSync,
+			logger,
+			statuses[SyncTaskImpressionsCount],
+		),
+		statuses: statuses,
+	}
+
+	splitFactory := SplitFactory{
+		apikey:        apikey,
+		credentials:   credentials,
+		validationCfg: validationCfg,
+		cfg:           cfg,
+		metadata:      *metadata,
+		logger:        logger,
+		operationMode: "redis-standalone",
+		storages:      storages,
+		tasks:         syncTasks,
+		emitter:       emitter,
+		manualSync: &manualSync{
+			splitStorage:   notifyingSplits,
+			segmentStorage: notifyingSegments,
+			splitFetcher:   splitFetcher,
+			segmentFetcher: segmentFetcher,
+			syncLock:       syncLock,
+			statuses:       statuses,
+		},
+		readinessSubscriptors: make(map[int]chan int),
+	}
+	splitFactory.status.Store(sdkStatusInitializing)
+	watcher.attach(&splitFactory)
+
+	go splitFactory.initializationInMemory(readyChannel, nil, &syncTasks, false)
+
+	return &splitFactory, nil
+}
+
 func setupLocalhostFactory(
 	apikey string,
 	cfg *conf.SplitSdkConfig,
@@ -412,9 +1029,45 @@ func setupLocalhostFactory(
 	metadata *splitio.SdkMetadata,
 ) (*SplitFactory, error) {
 	splitStorage := mutexmap.NewMMSplitStorage()
+	segmentStorage := mutexmap.NewMMSegmentStorage()
 	splitFetcher := local.NewFileSplitFetcher(cfg.SplitFile, logger)
+	if cfg.Advanced.LocalhostChangeListener != nil {
+		splitFetcher.AddListener(cfg.Advanced.LocalhostChangeListener)
+	}
 	splitPeriod := cfg.TaskPeriods.SplitSync
+
+	// Changes applied by synchronization are notified through SDK_UPDATE & split change listeners
+	emitter := newEventEmitter()
+	notifyingSplits := &notifyingSplitStorage{SplitStorage: splitStorage, notify: emitter.splitsChanged}
+	notifyingSegments := &notifyingSegmentStorage{SegmentStorage: segmentStorage, notify: emitter.update}
+
 	readyChannel := make(chan string, 1)
+	statuses := newTaskStatuses(SyncTaskSplits)
+	syncLock := tasks.NewSyncLock()
+
+	var segmentTask *asynctask.AsyncTask
+	var segmentFetcher service.SegmentFetcher
+	if local.IsJSONFile(cfg.SplitFile) {
+		segmentDirectory := cfg.SegmentDirectory
+		if segmentDirectory == "" {
+			segmentDirectory = filepath.Dir(cfg.SplitFile)
+		}
+		statuses[SyncTaskSegments] = tasks.NewTaskStatus()
+		segmentFetcher = local.NewFileSegmentFetcher(segmentDirectory, logger)
+		segmentTask = tasks.NewFetchSegmentsTask(
+			notifyingSplits,
+			notifyingSegments,
+			segmentFetcher,
+			cfg.TaskPeriods.SegmentSync,
+			cfg.Advanced.SegmentWorkers,
+			cfg.Advanced.SegmentQueueSize,
+			logger,
+			readyChannel,
+			false,
+			syncLock,
+			statuses[SyncTaskSegments],
+		)
+	}
 
 	splitFactory := &SplitFactory{
 		apikey:   apikey,
@@ -426,10 +1079,30 @@ func setupLocalhostFactory(
 			impressions: mutexqueue.NewMQImpressionsStorage(cfg.Advanced.ImpressionsQueueSize, make(chan string, 1), logger),
 			telemetry:   mutexmap.NewMMMetricsStorage(),
 			events:      mutexqueue.NewMQEventsStorage(cfg.Advanced.EventsQueueSize, make(chan string, 1), logger),
-			segments:    mutexmap.NewMMSegmentStorage(),
+			segments:    segmentStorage,
 		},
 		tasks: sdkSync{
-			splits: tasks.NewFetchSplitsTask(splitStorage, splitFetcher, splitPeriod, logger, readyChannel),
+			splits: tasks.NewFetchSplitsTask(
+				notifyingSplits,
+				splitFetcher,
+				splitPeriod,
+				logger,
+				readyChannel,
+				false,
+				syncLock,
+				statuses[SyncTaskSplits],
+			),
+			segments: segmentTask,
+			statuses: statuses,
+		},
+		emitter: emitter,
+		manualSync: &manualSync{
+			splitStorage:   notifyingSplits,
+			segmentStorage: notifyingSegments,
+			splitFetcher:   splitFetcher,
+			segmentFetcher: segmentFetcher,
+			syncLock:       syncLock,
+			statuses:       statuses,
 		},
 
 		readinessSubscriptors: make(map[int]chan int),
@@ -437,7 +1110,7 @@ func setupLocalhostFactory(
 	splitFactory.status.Store(sdkStatusInitializing)
 
 	// Call fetching tasks as goroutine
-	go splitFactory.initializationLocalhost(readyChannel, &splitFactory.tasks)
+	go splitFactory.initializationLocalhost(readyChannel, &splitFactory.tasks, splitFetcher)
 
 	return splitFactory, nil
 }
@@ -459,8 +1132,12 @@ func newFactory(apikey string, cfg *conf.SplitSdkConfig, logger logging.LoggerIn
 		splitFactory, err = setupInMemoryFactory(apikey, cfg, logger, &metadata)
 	case "redis-consumer":
 		splitFactory, err = setupRedisFactory(apikey, cfg, logger, &metadata)
+	case "redis-standalone":
+		splitFactory, err = setupRedisStandaloneFactory(apikey, cfg, logger, &metadata)
 	case "localhost":
 		splitFactory, err = setupLocalhostFactory(apikey, cfg, logger, &metadata)
+	case "offline":
+		splitFactory, err = setupOfflineFactory(apikey, cfg, logger, &metadata)
 	default:
 		err = fmt.Errorf("Invalid operation mode \"%s\"", cfg.OperationMode)
 	}
@@ -476,5 +1153,13 @@ func newFactory(apikey string, cfg *conf.SplitSdkConfig, logger logging.LoggerIn
 		)
 	}
 
+	if cfg.ImpressionsMode == conf.ImpressionsModeOptimized {
+		splitFactory.impressionObserver = impressions.NewObserver(cfg.Advanced.ImpressionsObserverSize)
+	}
+
+	if cfg.BlockUntilReady > 0 {
+		splitFactory.watchReadyTimeout(time.Duration(cfg.BlockUntilReady) * time.Second)
+	}
+
 	return splitFactory, nil
 }
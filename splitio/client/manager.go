@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/splitio/go-client/splitio/service/dtos"
@@ -18,12 +19,13 @@ type SplitManager struct {
 
 // SplitView is a partial representation of a currently stored split
 type SplitView struct {
-	Name         string            `json:"name"`
-	TrafficType  string            `json:"trafficType"`
-	Killed       bool              `json:"killed"`
-	Treatments   []string          `json:"treatments"`
-	ChangeNumber int64             `json:"changeNumber"`
-	Configs      map[string]string `json:"configs"`
+	Name             string            `json:"name"`
+	TrafficType      string            `json:"trafficType"`
+	Killed           bool              `json:"killed"`
+	Treatments       []string          `json:"treatments"`
+	ChangeNumber     int64             `json:"changeNumber"`
+	Configs          map[string]string `json:"configs"`
+	DefaultTreatment string            `json:"defaultTreatment"`
 }
 
 func newSplitView(splitDto *dtos.SplitDTO) *SplitView {
@@ -34,12 +36,13 @@ func newSplitView(splitDto *dtos.SplitDTO) *SplitView {
 		}
 	}
 	return &SplitView{
-		ChangeNumber: splitDto.ChangeNumber,
-		Killed:       splitDto.Killed,
-		Name:         splitDto.Name,
-		TrafficType:  splitDto.TrafficTypeName,
-		Treatments:   treatments,
-		Configs:      splitDto.Configurations,
+		ChangeNumber:     splitDto.ChangeNumber,
+		Killed:           splitDto.Killed,
+		Name:             splitDto.Name,
+		TrafficType:      splitDto.TrafficTypeName,
+		Treatments:       treatments,
+		Configs:          splitDto.Configurations,
+		DefaultTreatment: splitDto.DefaultTreatment,
 	}
 }
 
@@ -106,6 +109,11 @@ func (m *SplitManager) BlockUntilReady(timer int) error {
 	return m.factory.BlockUntilReady(timer)
 }
 
+// BlockUntilReadyCtx Calls BlockUntilReadyCtx on factory to block manager on readiness
+func (m *SplitManager) BlockUntilReadyCtx(ctx context.Context) error {
+	return m.factory.BlockUntilReadyCtx(ctx)
+}
+
 func (m *SplitManager) isDestroyed() bool {
 	return m.factory.IsDestroyed()
 }
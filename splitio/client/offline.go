@@ -0,0 +1,143 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/splitio/go-client/splitio"
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-client/splitio/storage/filelog"
+	"github.com/splitio/go-client/splitio/storage/mutexmap"
+	"github.com/splitio/go-toolkit/datastructures/set"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// decodeFile decodes the JSON contents of a file
+func decodeFile(path string, target interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(target)
+}
+
+// loadOfflineSplits puts the active splits of the configured splitChanges response in the storage
+func loadOfflineSplits(cfg *conf.OfflineConfig, splitStorage storage.SplitStorageProducer) (int, error) {
+	var splitChanges dtos.SplitChangesDTO
+	var err error
+	if cfg.SplitChanges != nil {
+		err = json.NewDecoder(cfg.SplitChanges).Decode(&splitChanges)
+	} else {
+		err = decodeFile(cfg.SplitChangesFile, &splitChanges)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Offline mode: split changes could not be read: %s", err.Error())
+	}
+
+	activeSplits := make([]dtos.SplitDTO, 0, len(splitChanges.Splits))
+	for _, split := range splitChanges.Splits {
+		if split.Status == "ACTIVE" {
+			activeSplits = append(activeSplits, split)
+		}
+	}
+	splitStorage.PutMany(activeSplits, splitChanges.Till)
+	return len(activeSplits), nil
+}
+
+// loadOfflineSegments puts the configured segmentChanges responses in the storage. Responses for the same segment
+// are applied in order
+func loadOfflineSegments(cfg *conf.OfflineConfig, segmentStorage storage.SegmentStorage) error {
+	segmentChanges := make([]dtos.SegmentChangesDTO, 0, len(cfg.SegmentChangesFiles)+len(cfg.SegmentChanges))
+	for _, path := range cfg.SegmentChangesFiles {
+		var changes dtos.SegmentChangesDTO
+		if err := decodeFile(path, &changes); err != nil {
+			return fmt.Errorf("Offline mode: segment changes could not be read from %s: %s", path, err.Error())
+		}
+		segmentChanges = append(segmentChanges, changes)
+	}
+	for index, reader := range cfg.SegmentChanges {
+		var changes dtos.SegmentChangesDTO
+		if err := json.NewDecoder(reader).Decode(&changes); err != nil {
+			return fmt.Errorf("Offline mode: segment changes could not be read from reader %d: %s", index, err.Error())
+		}
+		segmentChanges = append(segmentChanges, changes)
+	}
+
+	for _, changes := range segmentChanges {
+		if changes.Name == "" {
+			return errors.New("Offline mode: segment changes must include the segment name")
+		}
+		keys := segmentStorage.Get(changes.Name)
+		if keys == nil {
+			keys = set.NewSet()
+		}
+		for _, key := range changes.Added {
+			keys.Add(key)
+		}
+		for _, key := range changes.Removed {
+			keys.Remove(key)
+		}
+		segmentStorage.Put(changes.Name, keys, changes.Till)
+	}
+	return nil
+}
+
+func setupOfflineFactory(
+	apikey string,
+	cfg *conf.SplitSdkConfig,
+	logger logging.LoggerInterface,
+	metadata *splitio.SdkMetadata,
+) (*SplitFactory, error) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	splitCount, err := loadOfflineSplits(&cfg.Offline, splitStorage)
+	if err != nil {
+		return nil, err
+	}
+	if err = loadOfflineSegments(&cfg.Offline, segmentStorage); err != nil {
+		return nil, err
+	}
+	for _, name := range splitStorage.SegmentNames().List() {
+		if segmentName, ok := name.(string); ok && segmentStorage.Get(segmentName) == nil {
+			logger.Warning(fmt.Sprintf("Offline mode: segment %s is referenced by splits but was not supplied", segmentName))
+		}
+	}
+
+	impressionsLog, err := filelog.Open(cfg.Offline.ImpressionsFile)
+	if err != nil {
+		return nil, err
+	}
+	eventsLog, err := filelog.Open(cfg.Offline.EventsFile)
+	if err != nil {
+		impressionsLog.Close()
+		return nil, err
+	}
+
+	splitFactory := &SplitFactory{
+		apikey:        apikey,
+		cfg:           cfg,
+		metadata:      *metadata,
+		logger:        logger,
+		operationMode: "offline",
+		storages: sdkStorages{
+			splits:      splitStorage,
+			segments:    segmentStorage,
+			impressions: filelog.NewImpressionsStorage(impressionsLog),
+			telemetry:   mutexmap.NewMMMetricsStorage(),
+			events:      filelog.NewEventsStorage(eventsLog),
+			closers:     []io.Closer{impressionsLog, eventsLog},
+		},
+		emitter:               newEventEmitter(),
+		readinessSubscriptors: make(map[int]chan int),
+	}
+	splitFactory.status.Store(sdkStatusReady)
+	splitFactory.emitter.emit(SdkReady)
+	logger.Info(fmt.Sprintf("Offline mode: evaluating %d splits with change number %d", splitCount, splitStorage.Till()))
+	return splitFactory, nil
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-client/splitio/tasks"
+)
+
+// Names of the synchronization tasks reported by SyncStatus
+const (
+	SyncTaskSplits           = "splits"
+	SyncTaskSegments         = "segments"
+	SyncTaskImpressions      = "impressions"
+	SyncTaskGauges           = "gauges"
+	SyncTaskCounters         = "counters"
+	SyncTaskLatencies        = "latencies"
+	SyncTaskEvents           = "events"
+	SyncTaskImpressionsCount = "impressionsCount"
+	SyncTaskSnapshot         = "snapshot"
+)
+
+const manualSyncAttempts = 10
+
+// SyncResult holds the change numbers reflected by the storages once a manual synchronization finishes
+type SyncResult struct {
+	SplitsChangeNumber    int64
+	SegmentsChangeNumbers map[string]int64
+}
+
+// manualSync fetches split & segment changes on demand, sharing fetchers & statuses with the periodic tasks. The
+// periodic tasks wait on syncLock while a manual synchronization runs
+type manualSync struct {
+	splitStorage   storage.SplitStorage
+	segmentStorage storage.SegmentStorage
+	splitFetcher   service.SplitFetcher
+	segmentFetcher service.SegmentFetcher
+	syncLock       *tasks.SyncLock
+	statuses       map[string]*tasks.TaskStatus
+}
+
+// newTaskStatuses returns a status for each of the given tasks
+func newTaskStatuses(names ...string) map[string]*tasks.TaskStatus {
+	statuses := make(map[string]*tasks.TaskStatus, len(names))
+	for _, name := range names {
+		statuses[name] = tasks.NewTaskStatus()
+	}
+	return statuses
+}
+
+// synchronize fetches splits until caught up and then every segment referenced by them. Segments are only
+// fetched when a segment fetcher is available. Fetching stops as soon as ctx is done
+func (s *manualSync) synchronize(ctx context.Context) (*SyncResult, error) {
+	s.syncLock.Lock()
+	defer s.syncLock.Unlock()
+
+	err := tasks.SynchronizeSplits(ctx, s.splitStorage, s.splitFetcher, 0, manualSyncAttempts)
+	s.statuses[SyncTaskSplits].Record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{
+		SplitsChangeNumber:    s.splitStorage.Till(),
+		SegmentsChangeNumbers: make(map[string]int64),
+	}
+	if s.segmentFetcher == nil {
+		return result, nil
+	}
+
+	for _, name := range s.splitStorage.SegmentNames().List() {
+		segmentName, ok := name.(string)
+		if !ok {
+			continue
+		}
+		err = tasks.SynchronizeSegment(ctx, s.segmentStorage, s.segmentFetcher, segmentName, 0, manualSyncAttempts)
+		s.statuses[SyncTaskSegments].Record(err)
+		if err != nil {
+			return nil, err
+		}
+		result.SegmentsChangeNumbers[segmentName] = s.segmentStorage.Till(segmentName)
+	}
+	return result, nil
+}
+
+// SyncNow fetches split & segment changes right away instead of waiting for the next polling period. It returns
+// the resulting change numbers once the storages have caught up with Split servers, or an error if the
+// synchronization fails or ctx is done first
+func (f *SplitFactory) SyncNow(ctx context.Context) (*SyncResult, error) {
+	if f.IsDestroyed() {
+		return nil, errors.New("Manual synchronization: Client is destroyed")
+	}
+	if err := f.AuthenticationError(); err != nil {
+		return nil, err
+	}
+	if f.manualSync == nil {
+		return nil, errors.New("Manual synchronization: not supported in " + f.cfg.OperationMode + " mode")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type outcome struct {
+		result *SyncResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := f.manualSync.synchronize(ctx)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SyncStatus returns the outcome of the last runs of each synchronization task, keyed by task name (SyncTaskSplits,
+// SyncTaskSegments, ...), including how many items recording tasks hold for retrying and have dropped. Tasks not used
+// in the current operation mode are not included
+func (f *SplitFactory) SyncStatus() map[string]tasks.Status {
+	result := make(map[string]tasks.Status, len(f.tasks.statuses))
+	for name, status := range f.tasks.statuses {
+		result[name] = status.Get()
+	}
+	return result
+}
@@ -1,12 +1,40 @@
 package conf
 
 const (
-	defaultHTTPTimeout        = 30
-	defaultTaskPeriod         = 30
-	defaultRedisHost          = "localhost"
-	defaultRedisPort          = 6379
-	defaultRedisDb            = 0
-	defaultSegmentQueueSize   = 500
-	defaultSegmentWorkers     = 10
-	defaultFeatureRefreshRate = 5
+	defaultHTTPTimeout             = 30
+	defaultTaskPeriod              = 30
+	defaultRedisHost               = "localhost"
+	defaultRedisPort               = 6379
+	defaultRedisDb                 = 0
+	defaultRedisClusterKeyHashTag  = "{SPLITIO}"
+	defaultSegmentQueueSize        = 500
+	defaultSegmentWorkers          = 10
+	defaultFeatureRefreshRate      = 5
+	defaultImpressionsCount        = 1800
+	defaultSpoolMaxSize            = 100 * 1024 * 1024
+	defaultSpoolSegmentSize        = 4 * 1024 * 1024
+	defaultFetchBackoffBase        = 1000
+	defaultFetchBackoffMax         = 60000
+	defaultFetchBackoffJitter      = 0.2
+	defaultFetchInitAttempts       = 5
+	defaultSnapshotPeriod          = 300
+	defaultImpressionsObserverSize = 500000
+)
+
+const (
+	// ImpressionsModeDebug queues every impression generated by the sdk
+	ImpressionsModeDebug = "debug"
+	// ImpressionsModeOptimized queues only the first occurrence of equivalent impressions within a time window
+	ImpressionsModeOptimized = "optimized"
+	// ImpressionsModeCount queues no impressions at all, only how many were generated per feature & time window
+	ImpressionsModeCount = "count"
+)
+
+const (
+	// SpoolSyncAlways flushes spooled data to disk on every write
+	SpoolSyncAlways = "always"
+	// SpoolSyncOnRotate flushes spooled data to disk each time a spool file is completed
+	SpoolSyncOnRotate = "rotate"
+	// SpoolSyncNever leaves flushing spooled data to the operating system
+	SpoolSyncNever = "never"
 )
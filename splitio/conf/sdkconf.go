@@ -5,6 +5,9 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os/user"
 	"path"
 	"strings"
@@ -19,16 +22,21 @@ import (
 // struct used to setup a Split.io SDK client.
 //
 // Parameters:
-// - OperationMode (Required) Must be one of ["inmemory-standalone", "redis-consumer", "redis-standalone"]
+// - OperationMode (Required) Must be one of ["inmemory-standalone", "redis-consumer", "redis-standalone", "offline"]
 // - InstanceName (Optional) Name to be used when submitting metrics & impressions to split servers
 // - IPAddress (Optional) Address to be used when submitting metrics & impressions to split servers
-// - BlockUntilReady (Optional) How much to wait until the sdk is ready
+// - BlockUntilReady (Optional) Seconds to wait until the sdk is ready. Once elapsed, SDK_READY_TIMED_OUT is emitted while synchronization continues
 // - SplitFile (Optional) File with splits to use when running in localhost mode
+// - SegmentDirectory (Optional) Directory with <segment>.json files used along a JSON SplitFile. Defaults to its directory
 // - LabelsEnabled (Optional) Can be used to disable labels if the user does not want to send that info to split servers.
+// - ImpressionsMode (Optional) One of ["debug", "optimized", "count"]. Defaults to "debug", which queues every impression
 // - Logger: (Optional) Custom logger complying with logging.LoggerInterface
 // - LoggerConfig: (Optional) Options to setup the sdk's own logger
 // - TaskPeriods: (Optional) How often should each task run
 // - Redis: (Required for "redis-consumer" & "redis-standalone" operation modes. Sets up Redis config
+// - Spool: (Optional) Sets up a disk-backed spool for impressions & events in "inmemory-standalone" mode
+// - Snapshot: (Optional) Starts "inmemory-standalone" mode from a snapshot of splits & segments, which is written periodically
+// - Offline: (Required for "offline" operation mode) Sets up the definitions evaluated & where impressions & events are written
 // - Advanced: (Optional) Sets up various advanced options for the sdk
 type SplitSdkConfig struct {
 	OperationMode      string
@@ -37,34 +45,103 @@ type SplitSdkConfig struct {
 	IPAddressesEnabled bool
 	BlockUntilReady    int
 	SplitFile          string
+	SegmentDirectory   string
 	LabelsEnabled      bool
+	ImpressionsMode    string
 	SplitSyncProxyURL  string
 	Logger             logging.LoggerInterface
 	LoggerConfig       logging.LoggerOptions
 	TaskPeriods        TaskPeriods
 	Advanced           AdvancedConfig
 	Redis              RedisConfig
+	Spool              SpoolConfig
+	Snapshot           SnapshotConfig
+	Offline            OfflineConfig
 }
 
 // TaskPeriods struct is used to configure the period for each synchronization task
 type TaskPeriods struct {
-	SplitSync      int
-	SegmentSync    int
-	ImpressionSync int
-	GaugeSync      int
-	CounterSync    int
-	LatencySync    int
-	EventsSync     int
+	SplitSync            int
+	SegmentSync          int
+	ImpressionSync       int
+	GaugeSync            int
+	CounterSync          int
+	LatencySync          int
+	EventsSync           int
+	ImpressionsCountSync int
 }
 
 // RedisConfig struct is used to cofigure the redis parameters
+//   - Host, Port & Database - Single redis node to connect to, unless sentinel or cluster addresses are set
+//   - SentinelMaster & SentinelAddresses - Name of the master monitored by sentinel & addresses of the sentinels
+//   - ClusterNodes - Addresses of the seed nodes of a redis cluster. Can't be combined with sentinel
+//   - ClusterKeyHashTag - Hash tag (ie: "{SPLITIO}") prepended to every key in cluster mode, so that every key falls
+//     in the same slot. Defaults to "{SPLITIO}"
 type RedisConfig struct {
-	Host      string
-	Port      int
-	Database  int
-	Password  string
-	Prefix    string
-	TLSConfig *tls.Config
+	Host              string
+	Port              int
+	Database          int
+	Password          string
+	Prefix            string
+	TLSConfig         *tls.Config
+	SentinelMaster    string
+	SentinelAddresses []string
+	ClusterNodes      []string
+	ClusterKeyHashTag string
+}
+
+// SpoolConfig struct is used to keep impressions & events on disk until they're posted, so that they survive
+// connectivity losses & restarts
+// - Directory - Where spool files are written. The spool is disabled when empty
+// - MaxSize - Maximum amount of bytes kept on disk by each spool. Writes are rejected once it is reached
+// - SegmentSize - Size in bytes after which a new spool file is started
+// - SyncPolicy - One of ["always", "rotate", "never"]. When written data is flushed to disk
+type SpoolConfig struct {
+	Directory   string
+	MaxSize     int64
+	SegmentSize int64
+	SyncPolicy  string
+}
+
+// SnapshotConfig struct is used to start from previously synchronized splits & segments, becoming ready right away
+// and fetching only the changes applied since the snapshot was taken
+// - File - Where snapshots are periodically written & read at startup. Snapshots are disabled when empty
+// - Period - Seconds between snapshot writes
+// - Bootstrap - Snapshot contents to start from instead of File, as written by another instance
+type SnapshotConfig struct {
+	File      string
+	Period    int
+	Bootstrap []byte
+}
+
+// OfflineConfig struct is used to evaluate definitions in "offline" operation mode, which never contacts Split servers
+// - SplitChangesFile - File with a splitChanges response holding the splits to evaluate
+// - SplitChanges - Reader of a splitChanges response, used instead of SplitChangesFile
+// - SegmentChangesFiles - Files with a segmentChanges response each, holding the segments referenced by the splits
+// - SegmentChanges - Readers of segmentChanges responses, used along with SegmentChangesFiles
+// - ImpressionsFile - File impressions are appended to as JSON lines. Impressions are discarded when empty
+// - EventsFile - File events are appended to as JSON lines. Events are discarded when empty
+type OfflineConfig struct {
+	SplitChangesFile    string
+	SplitChanges        io.Reader
+	SegmentChangesFiles []string
+	SegmentChanges      []io.Reader
+	ImpressionsFile     string
+	EventsFile          string
+}
+
+// HTTPInterceptor is called with every request made to Split servers. It can inspect & mutate the request before
+// passing it on to next, and inspect & mutate the response (or error) next returns. Interceptors can also answer
+// requests on their own, without calling next
+type HTTPInterceptor func(req *http.Request, next http.RoundTripper) (*http.Response, error)
+
+type interceptedTransport struct {
+	interceptor HTTPInterceptor
+	next        http.RoundTripper
+}
+
+func (t *interceptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.interceptor(req, t.next)
 }
 
 // AdvancedConfig exposes more configurable parameters that can be used to further tailor the sdk to the user's needs
@@ -72,17 +149,65 @@ type RedisConfig struct {
 // - HTTPTimeout - Timeout for HTTP requests when doing synchronization
 // - SegmentQueueSize - How many segments can be queued for updating (should be >= # segments the user has)
 // - SegmentWorkers - How many workers will be used when performing segments sync.
+// - StreamingEnabled - Keep splits & segments up to date through push notifications, using polling only as fallback
+// - StreamingServiceURL - URL of the streaming (SSE) service. Required when StreamingEnabled is set
+// - LocalhostChangeListener - Function called with the names of the splits that changed when the localhost file is edited
+// - FetchBackoffBase - Milliseconds to wait before retrying a failed split or segment fetch, doubled on each failure. A negative value disables retries
+// - FetchBackoffMax - Maximum milliseconds to wait before retrying a failed split or segment fetch
+// - FetchBackoffJitter - Fraction (0 to 1) of each retry delay & sync period that is randomized
+// - FetchInitAttempts - How many times splits & segments are fetched during initialization before giving up
+// - HTTPTransport - Custom round tripper used for every request made to Split servers. Can't be combined with TLSConfig & ProxyURL
+// - TLSConfig - TLS settings (CA bundle, client certificates) used for every request made to Split servers
+// - ProxyURL - HTTP proxy used for every request made to Split servers. Credentials can be supplied as user info
+// - HTTPInterceptors - Chain of interceptors called with every request made to Split servers, first one outermost
+// - CompressionThreshold - Size in bytes from which posted bulks are compressed with gzip. Compression is disabled when 0
+// - APIKeyProvider - Function consulted on every request made to Split servers for the apikey to send. The factory's apikey is sent when it returns ""
+// - ImpressionsObserverSize - How many distinct impressions are remembered to deduplicate them in "optimized" impressions mode
 type AdvancedConfig struct {
-	ImpressionListener   impressionlistener.ImpressionListener
-	HTTPTimeout          int
-	SegmentQueueSize     int
-	SegmentWorkers       int
-	SdkURL               string
-	EventsURL            string
-	EventsBulkSize       int64
-	EventsQueueSize      int
-	ImpressionsQueueSize int
-	ImpressionsBulkSize  int64
+	ImpressionListener      impressionlistener.ImpressionListener
+	HTTPTimeout             int
+	SegmentQueueSize        int
+	SegmentWorkers          int
+	SdkURL                  string
+	EventsURL               string
+	EventsBulkSize          int64
+	EventsQueueSize         int
+	ImpressionsQueueSize    int
+	ImpressionsBulkSize     int64
+	StreamingEnabled        bool
+	StreamingServiceURL     string
+	LocalhostChangeListener func(changedSplits []string)
+	FetchBackoffBase        int
+	FetchBackoffMax         int
+	FetchBackoffJitter      float64
+	FetchInitAttempts       int
+	HTTPTransport           http.RoundTripper
+	TLSConfig               *tls.Config
+	ProxyURL                string
+	HTTPInterceptors        []HTTPInterceptor
+	CompressionThreshold    int
+	APIKeyProvider          func() string
+	ImpressionsObserverSize int
+	transport               http.RoundTripper
+}
+
+// Transport returns the round tripper to be used for requests made to Split servers, or nil for the default one
+func (c *AdvancedConfig) Transport() http.RoundTripper {
+	transport := c.transport
+	if c.HTTPTransport != nil {
+		transport = c.HTTPTransport
+	}
+	if len(c.HTTPInterceptors) == 0 {
+		return transport
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for index := len(c.HTTPInterceptors) - 1; index >= 0; index-- {
+		transport = &interceptedTransport{interceptor: c.HTTPInterceptors[index], next: transport}
+	}
+	return transport
 }
 
 // Default returns a config struct with all the default values
@@ -107,6 +232,7 @@ func Default() *SplitSdkConfig {
 	return &SplitSdkConfig{
 		OperationMode:      "inmemory-standalone",
 		LabelsEnabled:      true,
+		ImpressionsMode:    ImpressionsModeDebug,
 		IPAddress:          ipAddress,
 		IPAddressesEnabled: true,
 		InstanceName:       instanceName,
@@ -121,26 +247,42 @@ func Default() *SplitSdkConfig {
 			Prefix:    "",
 			TLSConfig: nil,
 		},
+		Spool: SpoolConfig{
+			MaxSize:     defaultSpoolMaxSize,
+			SegmentSize: defaultSpoolSegmentSize,
+			SyncPolicy:  SpoolSyncOnRotate,
+		},
+		Snapshot: SnapshotConfig{
+			Period: defaultSnapshotPeriod,
+		},
 		TaskPeriods: TaskPeriods{
-			CounterSync:    defaultTaskPeriod,
-			GaugeSync:      defaultTaskPeriod,
-			LatencySync:    defaultTaskPeriod,
-			ImpressionSync: defaultTaskPeriod,
-			SegmentSync:    defaultTaskPeriod,
-			SplitSync:      defaultFeatureRefreshRate,
-			EventsSync:     defaultTaskPeriod,
+			CounterSync:          defaultTaskPeriod,
+			GaugeSync:            defaultTaskPeriod,
+			LatencySync:          defaultTaskPeriod,
+			ImpressionSync:       defaultTaskPeriod,
+			SegmentSync:          defaultTaskPeriod,
+			SplitSync:            defaultFeatureRefreshRate,
+			EventsSync:           defaultTaskPeriod,
+			ImpressionsCountSync: defaultImpressionsCount,
 		},
 		Advanced: AdvancedConfig{
-			EventsURL:            "",
-			SdkURL:               "",
-			HTTPTimeout:          0,
-			ImpressionListener:   nil,
-			SegmentQueueSize:     500,
-			SegmentWorkers:       10,
-			EventsBulkSize:       5000,
-			EventsQueueSize:      10000,
-			ImpressionsQueueSize: 10000,
-			ImpressionsBulkSize:  5000,
+			EventsURL:               "",
+			SdkURL:                  "",
+			HTTPTimeout:             0,
+			ImpressionListener:      nil,
+			SegmentQueueSize:        500,
+			SegmentWorkers:          10,
+			EventsBulkSize:          5000,
+			EventsQueueSize:         10000,
+			ImpressionsQueueSize:    10000,
+			ImpressionsBulkSize:     5000,
+			StreamingEnabled:        false,
+			StreamingServiceURL:     "",
+			FetchBackoffBase:        defaultFetchBackoffBase,
+			FetchBackoffMax:         defaultFetchBackoffMax,
+			FetchBackoffJitter:      defaultFetchBackoffJitter,
+			FetchInitAttempts:       defaultFetchInitAttempts,
+			ImpressionsObserverSize: defaultImpressionsObserverSize,
 		},
 	}
 }
@@ -149,7 +291,7 @@ func Default() *SplitSdkConfig {
 // returns an error if something is wrong
 func Normalize(apikey string, cfg *SplitSdkConfig) error {
 	// Fail if no apikey is provided
-	if apikey == "" && cfg.OperationMode != "localhost" {
+	if apikey == "" && cfg.OperationMode != "localhost" && cfg.OperationMode != "offline" {
 		return errors.New("Factory instantiation: you passed an empty apikey, apikey must be a non-empty string")
 	}
 
@@ -165,12 +307,81 @@ func Normalize(apikey string, cfg *SplitSdkConfig) error {
 		"inmemory-standalone",
 		"redis-consumer",
 		"redis-standalone",
+		"offline",
 	)
 
 	if !operationModes.Has(cfg.OperationMode) {
 		return fmt.Errorf("OperationMode parameter must be one of: %v", operationModes.List())
 	}
 
+	if cfg.OperationMode == "offline" && cfg.Offline.SplitChangesFile == "" && cfg.Offline.SplitChanges == nil {
+		return errors.New("Offline.SplitChangesFile or Offline.SplitChanges parameter is required in offline mode")
+	}
+
+	// Fail if an invalid impressions mode is provided. Configs not built from Default() get the debug mode
+	if cfg.ImpressionsMode == "" {
+		cfg.ImpressionsMode = ImpressionsModeDebug
+	}
+	impressionsModes := set.NewSet(ImpressionsModeDebug, ImpressionsModeOptimized, ImpressionsModeCount)
+	if !impressionsModes.Has(cfg.ImpressionsMode) {
+		return fmt.Errorf("ImpressionsMode parameter must be one of: %v", impressionsModes.List())
+	}
+	if cfg.Advanced.ImpressionsObserverSize <= 0 {
+		cfg.Advanced.ImpressionsObserverSize = defaultImpressionsObserverSize
+	}
+
+	if cfg.Spool.MaxSize <= 0 {
+		cfg.Spool.MaxSize = defaultSpoolMaxSize
+	}
+	if cfg.Spool.SegmentSize <= 0 {
+		cfg.Spool.SegmentSize = defaultSpoolSegmentSize
+	}
+	if cfg.Spool.SyncPolicy == "" {
+		cfg.Spool.SyncPolicy = SpoolSyncOnRotate
+	}
+	syncPolicies := set.NewSet(SpoolSyncAlways, SpoolSyncOnRotate, SpoolSyncNever)
+	if !syncPolicies.Has(cfg.Spool.SyncPolicy) {
+		return fmt.Errorf("Spool.SyncPolicy parameter must be one of: %v", syncPolicies.List())
+	}
+
+	if cfg.Snapshot.Period <= 0 {
+		cfg.Snapshot.Period = defaultSnapshotPeriod
+	}
+
+	if err := normalizeRedis(&cfg.Redis); err != nil {
+		return err
+	}
+
+	if cfg.Advanced.StreamingEnabled && cfg.Advanced.StreamingServiceURL == "" {
+		return errors.New("Advanced.StreamingServiceURL parameter is required when Advanced.StreamingEnabled is set")
+	}
+
+	// A negative base is kept, as it disables fetch retries
+	if cfg.Advanced.FetchBackoffBase == 0 {
+		cfg.Advanced.FetchBackoffBase = defaultFetchBackoffBase
+	}
+	if cfg.Advanced.FetchBackoffMax <= 0 {
+		cfg.Advanced.FetchBackoffMax = defaultFetchBackoffMax
+	}
+	if cfg.Advanced.FetchInitAttempts <= 0 {
+		cfg.Advanced.FetchInitAttempts = defaultFetchInitAttempts
+	}
+	if cfg.Advanced.FetchBackoffJitter < 0 || cfg.Advanced.FetchBackoffJitter > 1 {
+		return errors.New("Advanced.FetchBackoffJitter parameter must be between 0 and 1")
+	}
+
+	if cfg.Advanced.CompressionThreshold < 0 {
+		return errors.New("Advanced.CompressionThreshold parameter must be 0 (disabled) or a size in bytes")
+	}
+
+	if err := normalizeTransport(&cfg.Advanced); err != nil {
+		return err
+	}
+
+	if cfg.TaskPeriods.ImpressionsCountSync <= 0 {
+		cfg.TaskPeriods.ImpressionsCountSync = defaultImpressionsCount
+	}
+
 	if cfg.SplitSyncProxyURL != "" {
 		cfg.Advanced.SdkURL = cfg.SplitSyncProxyURL
 		cfg.Advanced.EventsURL = cfg.SplitSyncProxyURL
@@ -183,3 +394,53 @@ func Normalize(apikey string, cfg *SplitSdkConfig) error {
 
 	return nil
 }
+
+// normalizeTransport builds the round tripper used for requests made to Split servers out of the TLS & proxy
+// settings, so that every fetcher & recorder shares the same one
+func normalizeTransport(cfg *AdvancedConfig) error {
+	cfg.transport = nil
+	if cfg.TLSConfig == nil && cfg.ProxyURL == "" {
+		return nil
+	}
+	if cfg.HTTPTransport != nil {
+		return errors.New("Advanced.HTTPTransport parameter can't be combined with TLSConfig or ProxyURL")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSConfig != nil {
+		transport.TLSClientConfig = cfg.TLSConfig
+	}
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("Advanced.ProxyURL parameter is not a valid URL: %s", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	cfg.transport = transport
+	return nil
+}
+
+// normalizeRedis validates the sentinel & cluster parameters, setting the default cluster hash tag
+func normalizeRedis(cfg *RedisConfig) error {
+	if len(cfg.SentinelAddresses) > 0 && cfg.SentinelMaster == "" {
+		return errors.New("Redis.SentinelMaster parameter is required when Redis.SentinelAddresses is set")
+	}
+	if cfg.SentinelMaster != "" && len(cfg.SentinelAddresses) == 0 {
+		return errors.New("Redis.SentinelAddresses parameter is required when Redis.SentinelMaster is set")
+	}
+	if len(cfg.ClusterNodes) == 0 {
+		return nil
+	}
+	if len(cfg.SentinelAddresses) > 0 {
+		return errors.New("Redis.ClusterNodes parameter can't be combined with Redis.SentinelAddresses")
+	}
+	if cfg.ClusterKeyHashTag == "" {
+		cfg.ClusterKeyHashTag = defaultRedisClusterKeyHashTag
+	}
+	hashTag := cfg.ClusterKeyHashTag
+	if len(hashTag) < 3 || hashTag[0] != '{' || hashTag[len(hashTag)-1] != '}' || strings.ContainsAny(hashTag[1:len(hashTag)-1], "{}") {
+		return fmt.Errorf("Redis.ClusterKeyHashTag parameter must be a non-empty tag wrapped in braces, got %s", hashTag)
+	}
+	return nil
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/splitio/go-toolkit/logging"
 )
 
+// NoConditionIndex is returned as the matched condition index when the treatment was not decided by a condition
+const NoConditionIndex = -1
+
 // Engine struct is responsible for cheking if any of the conditions of the split matches,
 // performing traffic allocation, calculating the bucket and returning the appropriate treatment
 type Engine struct {
@@ -23,8 +26,20 @@ func (e *Engine) DoEvaluation(
 	bucketingKey string,
 	attributes map[string]interface{},
 ) (*string, string) {
+	treatment, label, _ := e.DoEvaluationWithIndex(split, key, bucketingKey, attributes)
+	return treatment, label
+}
+
+// DoEvaluationWithIndex performs the main evaluation against each condition, also returning the index of the
+// condition that matched, or NoConditionIndex if none did
+func (e *Engine) DoEvaluationWithIndex(
+	split *grammar.Split,
+	key string,
+	bucketingKey string,
+	attributes map[string]interface{},
+) (*string, string, int) {
 	inRollOut := false
-	for _, condition := range split.Conditions() {
+	for index, condition := range split.Conditions() {
 		if !inRollOut && condition.ConditionType() == grammar.ConditionTypeRollout {
 			if split.TrafficAllocation() < 100 {
 				bucket := e.calculateBucket(split.Algo(), bucketingKey, split.TrafficAllocationSeed())
@@ -34,7 +49,7 @@ func (e *Engine) DoEvaluation(
 							" Returning default treatment", split.Name(), key,
 					))
 					defaultTreatment := split.DefaultTreatment()
-					return &defaultTreatment, impressionlabels.NotInSplit
+					return &defaultTreatment, impressionlabels.NotInSplit, NoConditionIndex
 				}
 				inRollOut = true
 			}
@@ -43,10 +58,10 @@ func (e *Engine) DoEvaluation(
 		if condition.Matches(key, &bucketingKey, attributes) {
 			bucket := e.calculateBucket(split.Algo(), bucketingKey, split.Seed())
 			treatment := condition.CalculateTreatment(bucket)
-			return treatment, condition.Label()
+			return treatment, condition.Label(), index
 		}
 	}
-	return nil, impressionlabels.NoConditionMatched
+	return nil, impressionlabels.NoConditionMatched, NoConditionIndex
 }
 
 func (e *Engine) calculateBucket(algo int, bucketingKey string, seed int64) int {
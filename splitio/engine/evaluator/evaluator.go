@@ -20,13 +20,15 @@ const (
 )
 
 // Result represents the result of an evaluation, including the resulting treatment, the label for the impression,
-// the latency and error if any
+// the latency and error if any. MatchedConditionIndex is engine.NoConditionIndex when no condition decided the treatment
 type Result struct {
-	Treatment         string
-	Label             string
-	EvaluationTimeNs  int64
-	SplitChangeNumber int64
-	Config            *string
+	Treatment             string
+	Label                 string
+	EvaluationTimeNs      int64
+	SplitChangeNumber     int64
+	Config                *string
+	MatchedConditionIndex int
+	Killed                bool
 }
 
 // Results represents the result of multiple evaluations at once
@@ -62,7 +64,12 @@ func (e *Evaluator) evaluateTreatment(key string, bucketingKey string, feature s
 	var config *string
 	if splitDto == nil {
 		e.logger.Warning(fmt.Sprintf("Feature %s not found, returning control.", feature))
-		return &Result{Treatment: Control, Label: impressionlabels.SplitNotFound, Config: config}
+		return &Result{
+			Treatment:             Control,
+			Label:                 impressionlabels.SplitNotFound,
+			Config:                config,
+			MatchedConditionIndex: engine.NoConditionIndex,
+		}
 	}
 
 	ctx := injection.NewContext()
@@ -84,14 +91,16 @@ func (e *Evaluator) evaluateTreatment(key string, bucketingKey string, feature s
 		}
 
 		return &Result{
-			Treatment:         split.DefaultTreatment(),
-			Label:             impressionlabels.Killed,
-			SplitChangeNumber: split.ChangeNumber(),
-			Config:            config,
+			Treatment:             split.DefaultTreatment(),
+			Label:                 impressionlabels.Killed,
+			SplitChangeNumber:     split.ChangeNumber(),
+			Config:                config,
+			MatchedConditionIndex: engine.NoConditionIndex,
+			Killed:                true,
 		}
 	}
 
-	treatment, label := e.eng.DoEvaluation(split, key, bucketingKey, attributes)
+	treatment, label, conditionIndex := e.eng.DoEvaluationWithIndex(split, key, bucketingKey, attributes)
 
 	if treatment == nil {
 		e.logger.Warning(fmt.Sprintf(
@@ -109,10 +118,11 @@ func (e *Evaluator) evaluateTreatment(key string, bucketingKey string, feature s
 	}
 
 	return &Result{
-		Treatment:         *treatment,
-		Label:             label,
-		SplitChangeNumber: split.ChangeNumber(),
-		Config:            config,
+		Treatment:             *treatment,
+		Label:                 label,
+		SplitChangeNumber:     split.ChangeNumber(),
+		Config:                config,
+		MatchedConditionIndex: conditionIndex,
 	}
 }
 
@@ -144,7 +154,10 @@ func (e *Evaluator) EvaluateFeatures(key string, bucketingKey *string, features
 		bucketingKey = &key
 	}
 	for _, feature := range features {
-		results.Evaluations[feature] = *e.evaluateTreatment(key, *bucketingKey, feature, splits[feature], attributes)
+		evaluationStart := time.Now()
+		result := e.evaluateTreatment(key, *bucketingKey, feature, splits[feature], attributes)
+		result.EvaluationTimeNs = time.Since(evaluationStart).Nanoseconds()
+		results.Evaluations[feature] = *result
 	}
 
 	after := time.Now()
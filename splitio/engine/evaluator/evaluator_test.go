@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/engine"
 	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-toolkit/datastructures/set"
 	"github.com/splitio/go-toolkit/logging"
@@ -364,3 +365,29 @@ func TestMultipleEvaluations(t *testing.T) {
 		t.Error("It should be greater than 0")
 	}
 }
+
+func TestEvaluationDetails(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	evaluator := NewEvaluator(
+		&mockStorage{},
+		nil,
+		engine.NewEngine(logger),
+		logger)
+
+	key := "test"
+	result := evaluator.EvaluateFeature(key, &key, "mysplittest", nil)
+	if result.MatchedConditionIndex != 0 || result.Killed || result.SplitChangeNumber != 1494593336752 {
+		t.Error("Wrong evaluation details: ", result)
+	}
+
+	result = evaluator.EvaluateFeature(key, &key, "mysplittest3", nil)
+	if result.MatchedConditionIndex != engine.NoConditionIndex || !result.Killed || result.Label != "killed" {
+		t.Error("Killed split details not set properly: ", result)
+	}
+
+	result = evaluator.EvaluateFeature(key, &key, "nonexistant", nil)
+	if result.MatchedConditionIndex != engine.NoConditionIndex || result.Killed {
+		t.Error("Missing split details not set properly: ", result)
+	}
+}
@@ -20,3 +20,7 @@ const Exception = "exception"
 
 // ClientNotReady label will be returned when the client is not ready
 const ClientNotReady = "not ready"
+
+// ContextExpired label will be returned when the context supplied by the caller is cancelled or times out before
+// the evaluation completes
+const ContextExpired = "context expired"
@@ -0,0 +1,17 @@
+package impressions
+
+import (
+	"github.com/splitio/go-client/splitio/storage"
+)
+
+// CountByTimeFrame aggregates impressions per feature and time window
+func CountByTimeFrame(impressions []storage.Impression) map[storage.ImpressionsCountKey]int64 {
+	counts := make(map[storage.ImpressionsCountKey]int64)
+	for _, impression := range impressions {
+		counts[storage.ImpressionsCountKey{
+			FeatureName: impression.FeatureName,
+			TimeFrame:   truncateTimeFrame(impression.Time),
+		}]++
+	}
+	return counts
+}
@@ -0,0 +1,107 @@
+// Package impressions contains the logic used to reduce the amount of impressions sent to Split servers
+// when the sdk runs in "optimized" or "count" impressions modes.
+package impressions
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-client/splitio/storage"
+)
+
+const (
+	// DefaultObserverSize is the amount of distinct impressions remembered by an observer
+	DefaultObserverSize = 500000
+
+	// TimeWindow is the period within which only the first occurrence of an impression is queued
+	TimeWindow = time.Hour
+)
+
+type observed struct {
+	hash uint64
+	time int64
+}
+
+// Observer struct remembers the last time each distinct impression was seen, evicting the least recently seen
+// ones once its capacity is reached
+type Observer struct {
+	size    int
+	entries map[uint64]*list.Element
+	lru     *list.List
+	mutex   sync.Mutex
+}
+
+// NewObserver instantiates an Observer that remembers up to size distinct impressions
+func NewObserver(size int) *Observer {
+	if size <= 0 {
+		size = DefaultObserverSize
+	}
+	return &Observer{
+		size:    size,
+		entries: make(map[uint64]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// hashImpression builds a hash out of the fields that make two impressions equivalent
+func hashImpression(impression *storage.Impression) uint64 {
+	hasher := fnv.New64a()
+	fmt.Fprintf(
+		hasher,
+		"%s:%s:%s:%s:%s:%d",
+		impression.KeyName,
+		impression.BucketingKey,
+		impression.FeatureName,
+		impression.Treatment,
+		impression.Label,
+		impression.ChangeNumber,
+	)
+	return hasher.Sum64()
+}
+
+// truncateTimeFrame returns the start of the time window a timestamp (in milliseconds) falls into
+func truncateTimeFrame(timestamp int64) int64 {
+	window := TimeWindow.Nanoseconds() / int64(time.Millisecond)
+	return timestamp - (timestamp % window)
+}
+
+// TestAndSet records the impression as seen at its time, returning the time it was previously seen or 0 if it wasn't
+func (o *Observer) TestAndSet(impression *storage.Impression) int64 {
+	hash := hashImpression(impression)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if element, ok := o.entries[hash]; ok {
+		entry := element.Value.(*observed)
+		previous := entry.time
+		entry.time = impression.Time
+		o.lru.MoveToFront(element)
+		return previous
+	}
+
+	o.entries[hash] = o.lru.PushFront(&observed{hash: hash, time: impression.Time})
+	if o.lru.Len() > o.size {
+		oldest := o.lru.Back()
+		o.lru.Remove(oldest)
+		delete(o.entries, oldest.Value.(*observed).hash)
+	}
+	return 0
+}
+
+// Process sets the previous time of each impression and returns only those that should be queued: the ones that
+// were not seen before within the current time window
+func (o *Observer) Process(impressions []storage.Impression) []storage.Impression {
+	toQueue := make([]storage.Impression, 0, len(impressions))
+	for index := range impressions {
+		impression := &impressions[index]
+		impression.Pt = o.TestAndSet(impression)
+		if impression.Pt == 0 || truncateTimeFrame(impression.Pt) != truncateTimeFrame(impression.Time) {
+			toQueue = append(toQueue, *impression)
+		}
+	}
+	return toQueue
+}
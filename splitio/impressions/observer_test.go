@@ -0,0 +1,98 @@
+package impressions
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-client/splitio/storage"
+)
+
+func TestObserverTestAndSet(t *testing.T) {
+	observer := NewObserver(2)
+	imp1 := storage.Impression{KeyName: "k1", FeatureName: "f1", Treatment: "on", Label: "l", ChangeNumber: 1, Time: 100}
+	imp2 := storage.Impression{KeyName: "k2", FeatureName: "f1", Treatment: "on", Label: "l", ChangeNumber: 1, Time: 200}
+	imp3 := storage.Impression{KeyName: "k3", FeatureName: "f1", Treatment: "on", Label: "l", ChangeNumber: 1, Time: 300}
+
+	if observer.TestAndSet(&imp1) != 0 {
+		t.Error("First occurrence should not have a previous time")
+	}
+
+	imp1.Time = 150
+	if observer.TestAndSet(&imp1) != 100 {
+		t.Error("Previous time should be returned")
+	}
+
+	observer.TestAndSet(&imp2)
+	observer.TestAndSet(&imp3)
+	if observer.TestAndSet(&imp1) != 0 {
+		t.Error("Least recently seen impression should have been evicted")
+	}
+
+	imp3.Treatment = "off"
+	if observer.TestAndSet(&imp3) != 0 {
+		t.Error("Impressions with different treatments should not be considered equivalent")
+	}
+}
+
+func TestObserverProcess(t *testing.T) {
+	observer := NewObserver(DefaultObserverSize)
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	hour := TimeWindow.Nanoseconds() / int64(time.Millisecond)
+
+	impressions := []storage.Impression{
+		{KeyName: "k1", FeatureName: "f1", Treatment: "on", Time: now},
+		{KeyName: "k1", FeatureName: "f1", Treatment: "on", Time: now + 10},
+		{KeyName: "k2", FeatureName: "f1", Treatment: "on", Time: now + 20},
+	}
+
+	toQueue := observer.Process(impressions)
+	if len(toQueue) != 2 {
+		t.Error("Only the first occurrence of each impression should be queued. Got: ", toQueue)
+	}
+
+	if impressions[1].Pt != now {
+		t.Error("Previous time should be recorded in every impression")
+	}
+
+	toQueue = observer.Process([]storage.Impression{{KeyName: "k1", FeatureName: "f1", Treatment: "on", Time: now + hour}})
+	if len(toQueue) != 1 || toQueue[0].Pt != now+10 {
+		t.Error("Impressions should be queued again once a new time window starts. Got: ", toQueue)
+	}
+}
+
+func TestObserverManyKeys(t *testing.T) {
+	observer := NewObserver(DefaultObserverSize)
+	impressions := make([]storage.Impression, 0, 2000)
+	for index := 0; index < 2000; index++ {
+		impressions = append(impressions, storage.Impression{
+			KeyName:     fmt.Sprintf("key%d", index),
+			FeatureName: "f1",
+			Treatment:   "on",
+			Time:        int64(1000 + index),
+		})
+	}
+
+	if toQueue := observer.Process(impressions); len(toQueue) != 2000 {
+		t.Error("Every distinct impression should be queued. Got: ", len(toQueue))
+	}
+	if toQueue := observer.Process(impressions); len(toQueue) != 0 {
+		t.Error("Impressions seen within the time window should be deduplicated. Got: ", len(toQueue))
+	}
+}
+
+func TestCountByTimeFrame(t *testing.T) {
+	hour := TimeWindow.Nanoseconds() / int64(time.Millisecond)
+	counts := CountByTimeFrame([]storage.Impression{
+		{KeyName: "k1", FeatureName: "f1", Time: hour + 1},
+		{KeyName: "k2", FeatureName: "f1", Time: hour + 2},
+		{KeyName: "k1", FeatureName: "f1", Time: 2*hour + 1},
+		{KeyName: "k1", FeatureName: "f2", Time: hour + 1},
+	})
+
+	if counts[storage.ImpressionsCountKey{FeatureName: "f1", TimeFrame: hour}] != 2 ||
+		counts[storage.ImpressionsCountKey{FeatureName: "f1", TimeFrame: 2 * hour}] != 1 ||
+		counts[storage.ImpressionsCountKey{FeatureName: "f2", TimeFrame: hour}] != 1 {
+		t.Error("Wrong counts: ", counts)
+	}
+}
@@ -0,0 +1,321 @@
+// Package push contains the streaming subsystem that keeps splits and segments up to date by listening
+// to notifications sent by Split servers, instead of waiting for the next polling period.
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-client/splitio"
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-client/splitio/tasks"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const (
+	// StreamingReady is sent through the status channel when the stream is connected and notifications
+	// will be received from then on
+	StreamingReady = "STREAMING_READY"
+	// StreamingDown is sent through the status channel when the stream is lost and polling should resume
+	StreamingDown = "STREAMING_DOWN"
+)
+
+const (
+	// SplitUpdate notification type
+	SplitUpdate = "SPLIT_UPDATE"
+	// SplitKill notification type
+	SplitKill = "SPLIT_KILL"
+	// SegmentUpdate notification type
+	SegmentUpdate = "SEGMENT_UPDATE"
+)
+
+const (
+	minReconnectBackoff = 1 * time.Second
+	maxReconnectBackoff = 60 * time.Second
+	maxSyncAttempts     = 10
+	notificationsBuffer = 5000
+)
+
+type segmentNotification struct {
+	name         string
+	changeNumber int64
+}
+
+// Manager struct keeps a streaming connection alive and applies the notifications received through it
+type Manager struct {
+	sseClient      *SSEClient
+	headers        map[string]string
+	apikey         string
+	apikeyProvider func() string
+	splitStorage   storage.SplitStorage
+	segmentStorage storage.SegmentStorage
+	splitFetcher   service.SplitFetcher
+	segmentFetcher service.SegmentFetcher
+	statusChannel  chan string
+	splitQueue     chan int64
+	segmentQueue   chan segmentNotification
+	shutdown       chan struct{}
+	running        sync.WaitGroup
+	stopOnce       sync.Once
+	logger         logging.LoggerInterface
+}
+
+// NewManager instantiates a streaming manager. Status changes (StreamingReady / StreamingDown) are sent through
+// statusChannel, which is closed once the manager is stopped. The streaming service is the one set up in
+// Advanced.StreamingServiceURL
+func NewManager(
+	apikey string,
+	cfg *conf.SplitSdkConfig,
+	metadata *splitio.SdkMetadata,
+	splitStorage storage.SplitStorage,
+	segmentStorage storage.SegmentStorage,
+	splitFetcher service.SplitFetcher,
+	segmentFetcher service.SegmentFetcher,
+	statusChannel chan string,
+	logger logging.LoggerInterface,
+) *Manager {
+	return &Manager{
+		sseClient: NewSSEClient(cfg.Advanced.StreamingServiceURL, cfg.Advanced.Transport(), logger),
+		headers: map[string]string{
+			"SplitSDKVersion": metadata.SDKVersion,
+		},
+		apikey:         apikey,
+		apikeyProvider: cfg.Advanced.APIKeyProvider,
+		splitStorage:   splitStorage,
+		segmentStorage: segmentStorage,
+		splitFetcher:   splitFetcher,
+		segmentFetcher: segmentFetcher,
+		statusChannel:  statusChannel,
+		splitQueue:     make(chan int64, notificationsBuffer),
+		segmentQueue:   make(chan segmentNotification, notificationsBuffer),
+		shutdown:       make(chan struct{}),
+		logger:         logger,
+	}
+}
+
+// Start connects to the streaming service in background and starts processing notifications
+func (m *Manager) Start() {
+	m.running.Add(3)
+	go m.connectionLoop()
+	go m.splitWorker()
+	go m.segmentWorker()
+}
+
+// Stop closes the streaming connection, waits for the workers to finish and closes the status channel
+func (m *Manager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.shutdown)
+		m.sseClient.Shutdown()
+		m.running.Wait()
+		close(m.statusChannel)
+	})
+}
+
+func (m *Manager) isShuttingDown() bool {
+	select {
+	case <-m.shutdown:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *Manager) notifyStatus(status string) {
+	select {
+	case m.statusChannel <- status:
+	case <-m.shutdown:
+	}
+}
+
+// connectionHeaders returns the headers sent when connecting, along with the current apikey
+func (m *Manager) connectionHeaders() map[string]string {
+	apikey := m.apikey
+	if m.apikeyProvider != nil {
+		if provided := m.apikeyProvider(); provided != "" {
+			apikey = provided
+		}
+	}
+	headers := map[string]string{"Authorization": "Bearer " + apikey}
+	for name, value := range m.headers {
+		headers[name] = value
+	}
+	return headers
+}
+
+// connectionLoop keeps the stream connected, reconnecting with an exponential backoff every time it's lost
+func (m *Manager) connectionLoop() {
+	defer m.running.Done()
+	backoff := minReconnectBackoff
+	for !m.isShuttingDown() {
+		connected := false
+		err := m.sseClient.Connect(m.connectionHeaders(), func() {
+			connected = true
+			backoff = minReconnectBackoff
+			m.logger.Info("Streaming connection established")
+			m.notifyStatus(StreamingReady)
+			m.queueFullSync()
+		}, m.handleEvent)
+
+		if m.isShuttingDown() {
+			return
+		}
+
+		m.logger.Warning("Streaming connection lost or unavailable, polling will be used meanwhile: ", err)
+		if connected {
+			m.notifyStatus(StreamingDown)
+		}
+
+		select {
+		case <-m.shutdown:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// queueFullSync makes sure nothing was missed while the stream was not connected
+func (m *Manager) queueFullSync() {
+	m.queueSplitUpdate(0)
+	for _, name := range m.splitStorage.SegmentNames().List() {
+		segmentName, ok := name.(string)
+		if ok {
+			m.queueSegmentUpdate(segmentName, 0)
+		}
+	}
+}
+
+func (m *Manager) queueSplitUpdate(changeNumber int64) {
+	select {
+	case m.splitQueue <- changeNumber:
+	default:
+		m.logger.Error("Split notification queue is full, dropping update for change number ", changeNumber)
+	}
+}
+
+func (m *Manager) queueSegmentUpdate(name string, changeNumber int64) {
+	select {
+	case m.segmentQueue <- segmentNotification{name: name, changeNumber: changeNumber}:
+	default:
+		m.logger.Error("Segment notification queue is full, dropping update for segment ", name)
+	}
+}
+
+// parseNotification extracts a notification from an event, whether it is wrapped in the streaming envelope or not
+func parseNotification(data string) (*dtos.NotificationDTO, error) {
+	var message dtos.StreamingMessageDTO
+	err := json.Unmarshal([]byte(data), &message)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := data
+	if message.Data != "" {
+		payload = message.Data
+	}
+
+	var notification dtos.NotificationDTO
+	err = json.Unmarshal([]byte(payload), &notification)
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (m *Manager) handleEvent(event SSEEvent) {
+	if event.Event == "error" {
+		m.logger.Error("Error event received from streaming service: ", event.Data)
+		return
+	}
+
+	notification, err := parseNotification(event.Data)
+	if err != nil {
+		m.logger.Error("Error parsing streaming notification ", err)
+		return
+	}
+
+	m.logger.Debug(fmt.Sprintf("Streaming notification received: %+v", *notification))
+	switch notification.Type {
+	case SplitUpdate:
+		m.queueSplitUpdate(notification.ChangeNumber)
+	case SplitKill:
+		m.killLocally(notification.SplitName, notification.DefaultTreatment, notification.ChangeNumber)
+		m.queueSplitUpdate(notification.ChangeNumber)
+	case SegmentUpdate:
+		m.queueSegmentUpdate(notification.SegmentName, notification.ChangeNumber)
+	default:
+		m.logger.Debug("Ignoring streaming notification of type ", notification.Type)
+	}
+}
+
+// killLocally applies a kill right away so that evaluations don't have to wait for the split to be fetched
+func (m *Manager) killLocally(splitName string, defaultTreatment string, changeNumber int64) {
+	split := m.splitStorage.Get(splitName)
+	if split == nil || split.ChangeNumber >= changeNumber {
+		return
+	}
+	split.Killed = true
+	split.DefaultTreatment = defaultTreatment
+	split.ChangeNumber = changeNumber
+	m.splitStorage.PutMany([]dtos.SplitDTO{*split}, m.splitStorage.Till())
+}
+
+func (m *Manager) splitWorker() {
+	defer m.running.Done()
+	for {
+		select {
+		case <-m.shutdown:
+			return
+		case changeNumber := <-m.splitQueue:
+			if changeNumber > 0 && m.splitStorage.Till() >= changeNumber {
+				continue
+			}
+			err := tasks.SynchronizeSplits(context.Background(), m.splitStorage, m.splitFetcher, changeNumber, maxSyncAttempts)
+			if err != nil {
+				m.logger.Error("Error synchronizing splits after notification: ", err)
+				continue
+			}
+			// Splits may now reference segments that have never been fetched
+			for _, name := range m.splitStorage.SegmentNames().List() {
+				segmentName, ok := name.(string)
+				if ok && m.segmentStorage.Get(segmentName) == nil {
+					m.queueSegmentUpdate(segmentName, 0)
+				}
+			}
+		}
+	}
+}
+
+func (m *Manager) segmentWorker() {
+	defer m.running.Done()
+	for {
+		select {
+		case <-m.shutdown:
+			return
+		case notification := <-m.segmentQueue:
+			if notification.changeNumber > 0 && m.segmentStorage.Till(notification.name) >= notification.changeNumber {
+				continue
+			}
+			err := tasks.SynchronizeSegment(
+				context.Background(),
+				m.segmentStorage,
+				m.segmentFetcher,
+				notification.name,
+				notification.changeNumber,
+				maxSyncAttempts,
+			)
+			if err != nil {
+				m.logger.Error(fmt.Sprintf("Error synchronizing segment %s after notification: ", notification.name), err)
+			}
+		}
+	}
+}
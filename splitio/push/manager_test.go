@@ -0,0 +1,208 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-client/splitio"
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/api"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage/mutexmap"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// streamingServerMock serves split & segment changes and an SSE endpoint through which tests can push notifications
+type streamingServerMock struct {
+	mutex         sync.Mutex
+	splitTill     int64
+	segmentTill   int64
+	notifications chan string
+	disconnect    chan struct{}
+}
+
+func (s *streamingServerMock) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		splitTill := s.splitTill
+		segmentTill := s.segmentTill
+		s.mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/splitChanges":
+			since := r.URL.Query().Get("since")
+			changes := dtos.SplitChangesDTO{Since: splitTill, Till: splitTill, Splits: []dtos.SplitDTO{}}
+			if since != fmt.Sprintf("%d", splitTill) {
+				changes.Since = -1
+				changes.Splits = []dtos.SplitDTO{{
+					Name:             "split1",
+					Status:           "ACTIVE",
+					ChangeNumber:     splitTill,
+					DefaultTreatment: "off",
+					Conditions: []dtos.ConditionDTO{{
+						MatcherGroup: dtos.MatcherGroupDTO{
+							Matchers: []dtos.MatcherDTO{{
+								MatcherType:        "IN_SEGMENT",
+								UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "segment1"},
+							}},
+						},
+					}},
+				}}
+			}
+			raw, _ := json.Marshal(changes)
+			w.Write(raw)
+		case "/segmentChanges/segment1":
+			since := r.URL.Query().Get("since")
+			changes := dtos.SegmentChangesDTO{Name: "segment1", Since: segmentTill, Till: segmentTill}
+			if since != fmt.Sprintf("%d", segmentTill) {
+				changes.Since = -1
+				changes.Added = []string{fmt.Sprintf("key%d", segmentTill)}
+			}
+			raw, _ := json.Marshal(changes)
+			w.Write(raw)
+		case "/sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case notification := <-s.notifications:
+					message, _ := json.Marshal(dtos.StreamingMessageDTO{ID: "1", Channel: "splits", Data: notification})
+					fmt.Fprintf(w, "event: message\ndata: %s\n\n", message)
+					w.(http.Flusher).Flush()
+				case <-s.disconnect:
+					return
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			t.Error("Unexpected request to ", r.URL.Path)
+		}
+	}
+}
+
+func (s *streamingServerMock) update(splitTill int64, segmentTill int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.splitTill = splitTill
+	s.segmentTill = segmentTill
+}
+
+func waitForStatus(t *testing.T, statusChannel chan string, expected string) {
+	select {
+	case status := <-statusChannel:
+		if status != expected {
+			t.Error("Unexpected status ", status)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("Status not received: ", expected)
+	}
+}
+
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func TestStreamingManager(t *testing.T) {
+	server := &streamingServerMock{
+		splitTill:     10,
+		segmentTill:   20,
+		notifications: make(chan string, 10),
+		disconnect:    make(chan struct{}),
+	}
+	ts := httptest.NewServer(server.handler(t))
+	defer ts.Close()
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	cfg := &conf.SplitSdkConfig{
+		Advanced: conf.AdvancedConfig{
+			SdkURL:              ts.URL,
+			EventsURL:           ts.URL,
+			StreamingEnabled:    true,
+			StreamingServiceURL: ts.URL + "/sse",
+		},
+	}
+
+	splitStorage := mutexmap.NewMMSplitStorage()
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	statusChannel := make(chan string, 10)
+	manager := NewManager(
+		"someApikey",
+		cfg,
+		&splitio.SdkMetadata{SDKVersion: "go-test"},
+		splitStorage,
+		segmentStorage,
+		api.NewHTTPSplitFetcher("someApikey", cfg, logger),
+		api.NewHTTPSegmentFetcher("someApikey", cfg, logger),
+		statusChannel,
+		logger,
+	)
+
+	manager.Start()
+	waitForStatus(t, statusChannel, StreamingReady)
+
+	// On connection a full sync is performed, which also brings segments referenced by splits
+	if !waitFor(func() bool { return splitStorage.Till() == 10 && segmentStorage.Till("segment1") == 20 }) {
+		t.Error("Splits and segments should have been synchronized on connection")
+	}
+
+	server.update(11, 20)
+	server.notifications <- `{"type":"SPLIT_UPDATE","changeNumber":11}`
+	if !waitFor(func() bool { return splitStorage.Till() == 11 }) {
+		t.Error("Splits should have been fetched after SPLIT_UPDATE")
+	}
+
+	server.notifications <- `{"type":"SPLIT_KILL","changeNumber":12,"splitName":"split1","defaultTreatment":"killed"}`
+	if !waitFor(func() bool {
+		split := splitStorage.Get("split1")
+		return split != nil && split.Killed && split.DefaultTreatment == "killed"
+	}) {
+		t.Error("Split should have been killed locally after SPLIT_KILL")
+	}
+
+	server.update(11, 21)
+	server.notifications <- `{"type":"SEGMENT_UPDATE","changeNumber":21,"segmentName":"segment1"}`
+	if !waitFor(func() bool { return segmentStorage.Till("segment1") == 21 }) {
+		t.Error("Segment should have been fetched after SEGMENT_UPDATE")
+	}
+	if contains, _ := segmentStorage.SegmentContainsKey("segment1", "key21"); !contains {
+		t.Error("Segment should contain the added key")
+	}
+
+	// Losing the connection should be reported
+	close(server.disconnect)
+	waitForStatus(t, statusChannel, StreamingDown)
+
+	manager.Stop()
+	for range statusChannel {
+		// Drain any status sent by reconnection attempts before stopping. The loop ends once the channel is closed
+	}
+}
+
+func TestParseNotification(t *testing.T) {
+	notification, err := parseNotification(`{"type":"SEGMENT_UPDATE","changeNumber":3,"segmentName":"s1"}`)
+	if err != nil || notification.Type != SegmentUpdate || notification.ChangeNumber != 3 || notification.SegmentName != "s1" {
+		t.Error("Plain notification not parsed properly", notification, err)
+	}
+
+	notification, err = parseNotification(`{"id":"x","channel":"splits","data":"{\"type\":\"SPLIT_UPDATE\",\"changeNumber\":5}"}`)
+	if err != nil || notification.Type != SplitUpdate || notification.ChangeNumber != 5 {
+		t.Error("Wrapped notification not parsed properly", notification, err)
+	}
+
+	_, err = parseNotification("not json")
+	if err == nil {
+		t.Error("An error should be returned for invalid payloads")
+	}
+}
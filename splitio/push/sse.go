@@ -0,0 +1,153 @@
+package push
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const defaultKeepAliveTimeout = 70
+
+// SSEEvent represents a single server-sent event
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// SSEClient struct consumes a text/event-stream endpoint
+type SSEClient struct {
+	url              string
+	httpClient       *http.Client
+	keepAliveTimeout time.Duration
+	cancel           context.CancelFunc
+	shutdown         bool
+	mutex            sync.Mutex
+	logger           logging.LoggerInterface
+}
+
+// NewSSEClient instantiates and returns an SSEClient. A nil transport stands for the default one
+func NewSSEClient(url string, transport http.RoundTripper, logger logging.LoggerInterface) *SSEClient {
+	return &SSEClient{
+		url:              url,
+		httpClient:       &http.Client{Transport: transport},
+		keepAliveTimeout: time.Duration(defaultKeepAliveTimeout) * time.Second,
+		logger:           logger,
+	}
+}
+
+// Connect opens the stream and blocks until it ends, calling onConnected once the server accepts the connection
+// and onEvent for every event received. It returns nil only if the stream was closed through Shutdown.
+func (c *SSEClient) Connect(headers map[string]string, onConnected func(), onEvent func(e SSEEvent)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.mutex.Lock()
+	if c.shutdown {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.cancel = cancel
+	c.mutex.Unlock()
+
+	req, err := http.NewRequest("GET", c.url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Accept", "text/event-stream")
+	req.Header.Add("Cache-Control", "no-cache")
+	for headerName, headerValue := range headers {
+		req.Header.Add(headerName, headerValue)
+	}
+
+	c.logger.Debug("[SSE] Connecting to ", c.url)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SSE connection: Status Code: %d - %s", resp.StatusCode, resp.Status)
+	}
+
+	onConnected()
+
+	// The server is expected to send at least a keepalive comment periodically. If nothing arrives within the
+	// timeout the connection is considered stale and torn down.
+	idle := time.AfterFunc(c.keepAliveTimeout, cancel)
+	defer idle.Stop()
+
+	err = readEvents(bufio.NewReader(resp.Body), onEvent, func() { idle.Reset(c.keepAliveTimeout) })
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.shutdown {
+		return nil
+	}
+	if err == io.EOF {
+		return errors.New("SSE connection closed by the server")
+	}
+	return err
+}
+
+// Shutdown closes the stream, making Connect return. The client cannot be reconnected afterwards
+func (c *SSEClient) Shutdown() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.shutdown = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// readEvents parses the stream line by line, dispatching an event every time a blank line is found
+func readEvents(reader *bufio.Reader, onEvent func(e SSEEvent), onActivity func()) error {
+	var event SSEEvent
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		onActivity()
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				onEvent(event)
+			}
+			event = SSEEvent{}
+			data = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			// Comment, used by the server as keepalive
+			continue
+		}
+
+		field, value := line, ""
+		if index := strings.Index(line, ":"); index >= 0 {
+			field, value = line[:index], strings.TrimPrefix(line[index+1:], " ")
+		}
+
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package push
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keepalive\n" +
+		"id: 1\n" +
+		"event: message\n" +
+		"data: first line\n" +
+		"data: second line\n" +
+		"\n" +
+		"event: error\r\n" +
+		"data:{\"message\":\"failed\"}\r\n" +
+		"\r\n" +
+		"event: message\n" +
+		"\n"
+
+	events := make([]SSEEvent, 0)
+	activity := 0
+	err := readEvents(bufio.NewReader(strings.NewReader(stream)), func(e SSEEvent) {
+		events = append(events, e)
+	}, func() { activity++ })
+
+	if err == nil {
+		t.Error("An error should be returned when the stream ends")
+	}
+
+	if activity != 11 {
+		t.Error("Every line should be reported as activity. Got: ", activity)
+	}
+
+	if len(events) != 2 {
+		t.Error("Two events should have been parsed. Got: ", len(events))
+		return
+	}
+
+	if events[0].ID != "1" || events[0].Event != "message" || events[0].Data != "first line\nsecond line" {
+		t.Error("Wrong first event: ", events[0])
+	}
+
+	if events[1].Event != "error" || events[1].Data != "{\"message\":\"failed\"}" {
+		t.Error("Wrong second event: ", events[1])
+	}
+}
+
+func TestSSEClientConnect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer someApikey" {
+			t.Error("Authorization header not sent")
+		}
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Error("Accept header not sent")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "event: message\ndata: %d\n\n", i)
+		}
+		w.(http.Flusher).Flush()
+	}))
+	defer ts.Close()
+
+	client := NewSSEClient(ts.URL, nil, logging.NewLogger(&logging.LoggerOptions{}))
+	var connected int32
+	var received int32
+	err := client.Connect(map[string]string{"Authorization": "Bearer someApikey"}, func() {
+		atomic.AddInt32(&connected, 1)
+	}, func(e SSEEvent) {
+		if e.Data != fmt.Sprintf("%d", atomic.LoadInt32(&received)) {
+			t.Error("Unexpected event data ", e.Data)
+		}
+		atomic.AddInt32(&received, 1)
+	})
+
+	if err == nil {
+		t.Error("An error should be returned when the server closes the connection")
+	}
+
+	if atomic.LoadInt32(&connected) != 1 {
+		t.Error("onConnected should have been called once")
+	}
+
+	if atomic.LoadInt32(&received) != 3 {
+		t.Error("3 events should have been received")
+	}
+}
+
+func TestSSEClientConnectionRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := NewSSEClient(ts.URL, nil, logging.NewLogger(&logging.LoggerOptions{}))
+	err := client.Connect(nil, func() {
+		t.Error("onConnected should not be called")
+	}, func(e SSEEvent) {})
+
+	if err == nil {
+		t.Error("An error should be returned")
+	}
+}
+
+func TestSSEClientShutdownAndKeepAlive(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	client := NewSSEClient(ts.URL, nil, logging.NewLogger(&logging.LoggerOptions{}))
+	client.keepAliveTimeout = 100 * time.Millisecond
+	err := client.Connect(nil, func() {}, func(e SSEEvent) {})
+	if err == nil {
+		t.Error("Stale connection should be reported as an error")
+	}
+
+	client.keepAliveTimeout = time.Minute
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Connect(nil, func() {}, func(e SSEEvent) {})
+	}()
+	time.Sleep(100 * time.Millisecond)
+	client.Shutdown()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("No error should be returned after shutdown. Got: ", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Connect should have returned after shutdown")
+	}
+}
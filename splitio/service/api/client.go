@@ -3,14 +3,18 @@ package api
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-toolkit/logging"
 )
 
@@ -33,6 +37,22 @@ func getUrls(cfg *conf.AdvancedConfig) (sdkURL string, eventsURL string) {
 	return sdkURL, eventsURL
 }
 
+// cachedResponse is the last body received for a path, along with the request it answered and its ETag
+type cachedResponse struct {
+	url  string
+	etag string
+	body []byte
+}
+
+// newClient returns an http.Client with the timeout & transport set up in the config
+func newClient(cfg *conf.AdvancedConfig) *http.Client {
+	timeout := defaultHTTPTimeout
+	if cfg.HTTPTimeout != 0 {
+		timeout = cfg.HTTPTimeout
+	}
+	return &http.Client{Timeout: time.Duration(timeout) * time.Second, Transport: cfg.Transport()}
+}
+
 // HTTPClient structure to wrap up the net/http.Client
 type HTTPClient struct {
 	url        string
@@ -40,7 +60,10 @@ type HTTPClient struct {
 	headers    map[string]string
 	logger     logging.LoggerInterface
 	apikey     string
+	provider   func() string
 	version    string
+	cache      map[string]cachedResponse
+	cacheMutex sync.Mutex
 }
 
 // NewHTTPClient instance of HttpClient
@@ -51,34 +74,81 @@ func NewHTTPClient(
 	version string,
 	logger logging.LoggerInterface,
 ) *HTTPClient {
-	var timeout int
-	if cfg.Advanced.HTTPTimeout != 0 {
-		timeout = cfg.Advanced.HTTPTimeout
-	} else {
-		timeout = defaultHTTPTimeout
-	}
-	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	client := newClient(&cfg.Advanced)
 	return &HTTPClient{
 		url:        endpoint,
 		httpClient: client,
 		logger:     logger,
 		apikey:     apikey,
+		provider:   cfg.Advanced.APIKeyProvider,
 		version:    version,
+		cache:      make(map[string]cachedResponse),
+	}
+}
+
+// cachePath returns the path a GET request is cached under. Only the last response for each path is kept, since
+// fetchers move on to a new change number as soon as they get one
+func cachePath(serviceURL string) string {
+	if index := strings.IndexByte(serviceURL, '?'); index >= 0 {
+		return serviceURL[:index]
+	}
+	return serviceURL
+}
+
+// cachedBody returns the ETag & body of the last response to a request, if it carried an ETag
+func (c *HTTPClient) cachedBody(serviceURL string) (string, []byte) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	cached, ok := c.cache[cachePath(serviceURL)]
+	if !ok || cached.url != serviceURL {
+		return "", nil
 	}
+	return cached.etag, cached.body
+}
+
+func (c *HTTPClient) cacheBody(serviceURL string, etag string, body []byte) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	if etag == "" {
+		delete(c.cache, cachePath(serviceURL))
+		return
+	}
+	c.cache[cachePath(serviceURL)] = cachedResponse{url: serviceURL, etag: etag, body: body}
+}
+
+// currentAPIKey returns the apikey to be sent with a request
+func (c *HTTPClient) currentAPIKey() string {
+	if c.provider != nil {
+		if apikey := c.provider(); apikey != "" {
+			return apikey
+		}
+	}
+	return c.apikey
 }
 
 // Get method is a get call to an url
 func (c *HTTPClient) Get(service string) ([]byte, error) {
+	return c.GetCtx(context.Background(), service)
+}
+
+// GetCtx behaves like Get, but the request is cancelled once ctx is done
+func (c *HTTPClient) GetCtx(ctx context.Context, service string) ([]byte, error) {
 
 	serviceURL := c.url + service
 	c.logger.Debug("[GET] ", serviceURL)
-	req, _ := http.NewRequest("GET", serviceURL, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", serviceURL, nil)
 
-	authorization := c.apikey
+	authorization := c.currentAPIKey()
 	c.logger.Debug("Authorization [ApiKey]: ", logging.ObfuscateAPIKey(authorization))
 	req.Header.Add("Accept-Encoding", "gzip")
 	req.Header.Add("Content-Type", "application/json")
 
+	// Responses that didn't change since the last request are not downloaded again
+	etag, cachedBody := c.cachedBody(serviceURL)
+	if etag != "" {
+		req.Header.Add("If-None-Match", etag)
+	}
+
 	c.logger.Debug(fmt.Sprintf("Headers: %v", req.Header))
 
 	req.Header.Add("Authorization", "Bearer "+authorization)
@@ -90,6 +160,11 @@ func (c *HTTPClient) Get(service string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		c.logger.Debug("[GET] ", serviceURL, " not modified")
+		return cachedBody, nil
+	}
+
 	// Check that the server actually sent compressed data
 	var reader io.ReadCloser
 	switch resp.Header.Get("Content-Encoding") {
@@ -108,23 +183,32 @@ func (c *HTTPClient) Get(service string) ([]byte, error) {
 
 	c.logger.Verbose("[RESPONSE_BODY]", string(body), "[END_RESPONSE_BODY]")
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.cacheBody(serviceURL, resp.Header.Get("ETag"), body)
+		return body, nil
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
 		return body, nil
 	}
 
-	return nil, fmt.Errorf("GET method: Status Code: %d - %s", resp.StatusCode, resp.Status)
+	return nil, &dtos.HTTPError{Method: "GET", Code: resp.StatusCode, Message: resp.Status}
 }
 
 // Post performs a HTTP POST request
 func (c *HTTPClient) Post(service string, body []byte, headers map[string]string) error {
+	return c.PostCtx(context.Background(), service, body, headers)
+}
+
+// PostCtx behaves like Post, but the request is cancelled once ctx is done
+func (c *HTTPClient) PostCtx(ctx context.Context, service string, body []byte, headers map[string]string) error {
 
 	serviceURL := c.url + service
 	c.logger.Debug("[POST] ", serviceURL)
-	req, _ := http.NewRequest("POST", serviceURL, bytes.NewBuffer(body))
+	req, _ := http.NewRequestWithContext(ctx, "POST", serviceURL, bytes.NewBuffer(body))
 	//****************
 	req.Close = true // To prevent EOF error when connection is closed
 	//****************
-	authorization := c.apikey
+	authorization := c.currentAPIKey()
 	c.logger.Debug("Authorization [ApiKey]: ", logging.ObfuscateAPIKey(authorization))
 
 	req.Header.Add("Accept-Encoding", "gzip")
@@ -159,13 +243,13 @@ func (c *HTTPClient) Post(service string, body []byte, headers map[string]string
 		return nil
 	}
 
-	return fmt.Errorf("POST method: Status Code: %d - %s", resp.StatusCode, resp.Status)
+	return &dtos.HTTPError{Method: "POST", Code: resp.StatusCode, Message: resp.Status}
 }
 
-// ValidateApikey validates apikey
-func ValidateApikey(apikey string, config conf.AdvancedConfig) error {
+// validationStatus requests a test segment with apikey, returning the status code of the response
+func validationStatus(apikey string, config conf.AdvancedConfig) (int, error) {
 	sdkURL, _ := getUrls(&config)
-	client := &http.Client{}
+	client := newClient(&config)
 
 	req, _ := http.NewRequest("GET", sdkURL+"/segmentChanges/___TEST___?since=-1", nil)
 	req.Header.Add("Accept-Encoding", "gzip")
@@ -173,13 +257,37 @@ func ValidateApikey(apikey string, config conf.AdvancedConfig) error {
 	req.Header.Add("Authorization", "Bearer "+apikey)
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// ValidateApikey validates apikey
+func ValidateApikey(apikey string, config conf.AdvancedConfig) error {
+	status, err := validationStatus(apikey, config)
+	if err != nil {
+		return err
+	}
 
-	if resp.StatusCode == 403 {
+	if status == 403 {
 		return errors.New("you passed a browser type apikey, please grab an apikey from the Split console that is of type sdk")
 	}
 
 	return nil
 }
+
+// ValidateRotatedApikey validates an apikey about to replace the one in use. Unlike ValidateApikey, apikeys
+// rejected with 401 (revoked, mistyped or from another environment) are reported too, as a *dtos.HTTPError
+func ValidateRotatedApikey(apikey string, config conf.AdvancedConfig) error {
+	status, err := validationStatus(apikey, config)
+	if err != nil {
+		return err
+	}
+
+	if status == 401 || status == 403 {
+		return &dtos.HTTPError{Method: "GET", Code: status, Message: http.StatusText(status)}
+	}
+
+	return nil
+}
@@ -3,13 +3,20 @@ package api
 
 import (
 	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/splitio/go-client/splitio"
 	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-toolkit/logging"
 )
 
@@ -53,6 +60,143 @@ func TestGetGZIP(t *testing.T) {
 	}
 }
 
+func TestGetNotModified(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == "\"v1\"" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "\"v1\"")
+		fmt.Fprintln(w, "Hello, client")
+	}))
+	defer ts.Close()
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	httpClient := NewHTTPClient("", &conf.SplitSdkConfig{}, ts.URL, splitio.Version, logger)
+	for i := 0; i < 2; i++ {
+		txt, err := httpClient.Get("/changes?since=1")
+		if err != nil || string(txt) != "Hello, client\n" {
+			t.Error("Unchanged responses should be returned from cache", string(txt), err)
+		}
+	}
+
+	// Cached bodies are only returned for the very same request
+	txt, _ := httpClient.Get("/changes?since=2")
+	if string(txt) != "Hello, client\n" || requests != 3 {
+		t.Error("Requests with other parameters should not be conditional", string(txt), requests)
+	}
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCustomTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, client")
+	}))
+	defer ts.Close()
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	transport := &countingTransport{}
+	cfg := &conf.SplitSdkConfig{Advanced: conf.AdvancedConfig{SdkURL: ts.URL, HTTPTransport: transport}}
+	httpClient := NewHTTPClient("", cfg, ts.URL, splitio.Version, logger)
+	httpClient.Get("/")
+	httpClient.Post("/", []byte("some text"), nil)
+	ValidateApikey("someApikey", cfg.Advanced)
+	if transport.requests != 3 {
+		t.Error("Every request should go through the custom transport. Got: ", transport.requests)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, client")
+	}))
+	defer ts.Close()
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	cfg := conf.Default()
+	if _, err := NewHTTPClient("", cfg, ts.URL, splitio.Version, logger).Get("/"); err == nil {
+		t.Error("Server certificate should not be trusted by default")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	cfg.Advanced.TLSConfig = &tls.Config{RootCAs: roots}
+	if err := conf.Normalize("someApikey", cfg); err != nil {
+		t.Error(err)
+	}
+	txt, err := NewHTTPClient("", cfg, ts.URL, splitio.Version, logger).Get("/")
+	if err != nil || string(txt) != "Hello, client\n" {
+		t.Error("Server certificate should be trusted through the supplied CA bundle", err)
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Request-Id") != "req-1" || r.Header.Get("X-Corp-Auth") != "token" {
+			t.Error("Headers added by interceptors should be sent", r.Header)
+		}
+		fmt.Fprintln(w, "Hello, client")
+	}))
+	defer ts.Close()
+
+	calls := make([]string, 0)
+	cfg := &conf.SplitSdkConfig{Advanced: conf.AdvancedConfig{HTTPInterceptors: []conf.HTTPInterceptor{
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			calls = append(calls, "auth")
+			req.Header.Set("X-Corp-Auth", "token")
+			return next.RoundTrip(req)
+		},
+		func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			calls = append(calls, "id")
+			req.Header.Set("X-Request-Id", "req-1")
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				calls = append(calls, fmt.Sprintf("status %d", resp.StatusCode))
+			}
+			return resp, err
+		},
+	}}}
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	httpClient := NewHTTPClient("", cfg, ts.URL, splitio.Version, logger)
+	if _, err := httpClient.Get("/"); err != nil {
+		t.Error(err)
+	}
+	if fmt.Sprint(calls) != "[auth id status 200]" {
+		t.Error("Interceptors should be called in order. Got: ", calls)
+	}
+
+	// Fault injection
+	inject := func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Header:     http.Header{},
+			Request:    req,
+		}, nil
+	}
+	cfg.Advanced.HTTPInterceptors = append(cfg.Advanced.HTTPInterceptors, inject)
+	httpClient = NewHTTPClient("", cfg, ts.URL, splitio.Version, logger)
+	_, err := httpClient.Get("/")
+	if httpError, ok := err.(*dtos.HTTPError); !ok || httpError.Code != 503 {
+		t.Error("Injected response should be returned. Got: ", err)
+	}
+	if err = httpClient.Post("/", []byte("some text"), nil); err == nil {
+		t.Error("Injected response should be returned for posts too")
+	}
+}
+
 func TestPost(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, "Hello, client")
@@ -66,3 +210,46 @@ func TestPost(t *testing.T) {
 		t.Error(errp)
 	}
 }
+
+func TestRequestsWithContext(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	httpClient := NewHTTPClient("", &conf.SplitSdkConfig{}, ts.URL, splitio.Version, logger)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := httpClient.GetCtx(ctx, "/"); err == nil {
+		t.Error("Get should fail once the context is done")
+	}
+	if err := httpClient.PostCtx(ctx, "/", []byte("some text"), nil); err == nil {
+		t.Error("Post should fail once the context is done")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Requests should be cancelled along with the context")
+	}
+}
+
+func TestAPIKeyProvider(t *testing.T) {
+	authorizations := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	apikey := ""
+	cfg := &conf.SplitSdkConfig{Advanced: conf.AdvancedConfig{APIKeyProvider: func() string { return apikey }}}
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	httpClient := NewHTTPClient("initial", cfg, ts.URL, splitio.Version, logger)
+	httpClient.Get("/")
+	apikey = "provided"
+	httpClient.Post("/", []byte("some text"), nil)
+	if fmt.Sprint(authorizations) != "[Bearer initial Bearer provided]" {
+		t.Error("Apikey should be consulted on every request. Got: ", authorizations)
+	}
+}
@@ -4,25 +4,60 @@ import (
 	"bytes"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/splitio/go-client/splitio"
 	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service"
 	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-toolkit/logging"
 )
 
 type httpFetcherBase struct {
-	client *HTTPClient
-	logger logging.LoggerInterface
+	client  *HTTPClient
+	backoff *service.Backoff
+	logger  logging.LoggerInterface
 }
 
-func (h *httpFetcherBase) fetchRaw(url string, since int64) ([]byte, error) {
+// newBackoff returns the retry policy set up in the config, or nil if fetches should not be retried (ie: when
+// FetchBackoffBase is negative)
+func newBackoff(cfg *conf.AdvancedConfig) *service.Backoff {
+	if cfg.FetchBackoffBase <= 0 {
+		return nil
+	}
+	backoff := &service.Backoff{
+		Base:         time.Duration(cfg.FetchBackoffBase) * time.Millisecond,
+		Max:          time.Duration(cfg.FetchBackoffMax) * time.Millisecond,
+		Jitter:       cfg.FetchBackoffJitter,
+		InitAttempts: cfg.FetchInitAttempts,
+	}
+	if backoff.Max < backoff.Base {
+		backoff.Max = backoff.Base
+	}
+	return backoff
+}
+
+// Backoff returns how failed fetches should be retried, or nil if they should not
+func (h *httpFetcherBase) Backoff() *service.Backoff {
+	return h.backoff
+}
+
+// fetchRaw requests changes since a change number. A till change number (> 0) is sent as a cache buster, so that
+// caching proxies don't answer with a response older than it
+func (h *httpFetcherBase) fetchRaw(url string, since int64, till int64) ([]byte, error) {
 	var bufferQuery bytes.Buffer
 	bufferQuery.WriteString(url)
 
+	separator := "?"
 	if since >= -1 {
 		bufferQuery.WriteString("?since=")
 		bufferQuery.WriteString(strconv.FormatInt(since, 10))
+		separator = "&"
+	}
+	if till > 0 {
+		bufferQuery.WriteString(separator)
+		bufferQuery.WriteString("till=")
+		bufferQuery.WriteString(strconv.FormatInt(till, 10))
 	}
 	data, err := h.client.Get(bufferQuery.String())
 	if err != nil {
@@ -45,15 +80,21 @@ func NewHTTPSplitFetcher(
 	sdkURL, _ := getUrls(&cfg.Advanced)
 	return &HTTPSplitFetcher{
 		httpFetcherBase: httpFetcherBase{
-			client: NewHTTPClient(apikey, cfg, sdkURL, splitio.Version, logger),
-			logger: logger,
+			client:  NewHTTPClient(apikey, cfg, sdkURL, splitio.Version, logger),
+			backoff: newBackoff(&cfg.Advanced),
+			logger:  logger,
 		},
 	}
 }
 
 // Fetch makes an http call to the split backend and returns the list of updated splits
 func (f *HTTPSplitFetcher) Fetch(since int64) (*dtos.SplitChangesDTO, error) {
-	data, err := f.fetchRaw("/splitChanges", since)
+	return f.FetchTill(since, 0)
+}
+
+// FetchTill returns the list of updated splits, bypassing cached responses older than the till change number
+func (f *HTTPSplitFetcher) FetchTill(since int64, till int64) (*dtos.SplitChangesDTO, error) {
+	data, err := f.fetchRaw("/splitChanges", since, till)
 	if err != nil {
 		f.logger.Error("Error fetching split changes ", err)
 		return nil, err
@@ -95,19 +136,26 @@ func NewHTTPSegmentFetcher(
 	sdkURL, _ := getUrls(&cfg.Advanced)
 	return &HTTPSegmentFetcher{
 		httpFetcherBase: httpFetcherBase{
-			client: NewHTTPClient(apikey, cfg, sdkURL, splitio.Version, logger),
-			logger: logger,
+			client:  NewHTTPClient(apikey, cfg, sdkURL, splitio.Version, logger),
+			backoff: newBackoff(&cfg.Advanced),
+			logger:  logger,
 		},
 	}
 }
 
 // Fetch issues a GET request to the split backend and returns the contents of a particular segment
 func (f *HTTPSegmentFetcher) Fetch(segmentName string, since int64) (*dtos.SegmentChangesDTO, error) {
+	return f.FetchTill(segmentName, since, 0)
+}
+
+// FetchTill returns the changes of a particular segment, bypassing cached responses older than the till change
+// number
+func (f *HTTPSegmentFetcher) FetchTill(segmentName string, since int64, till int64) (*dtos.SegmentChangesDTO, error) {
 	var bufferQuery bytes.Buffer
 	bufferQuery.WriteString("/segmentChanges/")
 	bufferQuery.WriteString(segmentName)
 
-	data, err := f.fetchRaw(bufferQuery.String(), since)
+	data, err := f.fetchRaw(bufferQuery.String(), since, till)
 	if err != nil {
 		f.logger.Error(err.Error())
 		return nil, err
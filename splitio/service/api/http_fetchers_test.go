@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/splitio/go-client/splitio/conf"
 	"github.com/splitio/go-toolkit/logging"
@@ -142,3 +143,56 @@ func TestSegmentChangesFetchHTTPError(t *testing.T) {
 		t.Error("Error expected but not found")
 	}
 }
+
+func TestSegmentChangesFetchTill(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprintln(w, string(segmentMock))
+	}))
+	defer ts.Close()
+
+	segmentFetcher := NewHTTPSegmentFetcher(
+		"",
+		&conf.SplitSdkConfig{
+			Advanced: conf.AdvancedConfig{
+				EventsURL: ts.URL,
+				SdkURL:    ts.URL,
+			},
+		},
+		logger,
+	)
+
+	if _, err := segmentFetcher.FetchTill("employees", 10, 20); err != nil {
+		t.Error("Error fetching segment", err)
+	}
+	if query != "since=10&till=20" {
+		t.Error("Till should be sent as a cache buster. Got: ", query)
+	}
+
+	segmentFetcher.Fetch("employees", 10)
+	if query != "since=10" {
+		t.Error("Till should only be sent when supplied. Got: ", query)
+	}
+}
+
+func TestFetchersBackoff(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	cfg := conf.Default()
+	conf.Normalize("asd", cfg)
+	splitFetcher := NewHTTPSplitFetcher("", cfg, logger)
+	backoff := splitFetcher.Backoff()
+	if backoff == nil || backoff.Base != time.Second || backoff.InitAttempts != cfg.Advanced.FetchInitAttempts {
+		t.Error("Default retry policy should be used. Got: ", backoff)
+	}
+
+	cfg = conf.Default()
+	cfg.Advanced.FetchBackoffBase = -1
+	conf.Normalize("asd", cfg)
+	if NewHTTPSegmentFetcher("", cfg, logger).Backoff() != nil {
+		t.Error("Retries should be disabled with a negative backoff base")
+	}
+}
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/splitio/go-client/splitio/storage"
 
@@ -12,21 +16,73 @@ import (
 )
 
 type httpRecorderBase struct {
-	client   *HTTPClient
-	logger   logging.LoggerInterface
-	metadata *splitio.SdkMetadata
+	client               *HTTPClient
+	logger               logging.LoggerInterface
+	metadata             *splitio.SdkMetadata
+	compressionThreshold int
+	compressionRejected  int32
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// post sends data compressed if it exceeds the compression threshold. If the server doesn't accept compressed
+// bodies, data is sent again uncompressed, and so is every further request
+func (h *httpRecorderBase) post(url string, data []byte, headers map[string]string) error {
+	if h.compressionThreshold <= 0 || len(data) < h.compressionThreshold ||
+		atomic.LoadInt32(&h.compressionRejected) == 1 {
+		return h.client.Post(url, data, headers)
+	}
+
+	compressed, err := compress(data)
+	if err != nil {
+		h.logger.Error("Error compressing request body, sending it uncompressed: ", err)
+		return h.client.Post(url, data, headers)
+	}
+	compressedHeaders := map[string]string{"Content-Encoding": "gzip"}
+	for name, value := range headers {
+		compressedHeaders[name] = value
+	}
+
+	err = h.client.Post(url, compressed, compressedHeaders)
+	if httpError, ok := err.(*dtos.HTTPError); ok && httpError.Code == http.StatusUnsupportedMediaType {
+		h.logger.Warning("Compressed request bodies are not supported by the server. Disabling compression")
+		atomic.StoreInt32(&h.compressionRejected, 1)
+		return h.client.Post(url, data, headers)
+	}
+	return err
 }
 
 func (h *httpRecorderBase) recordRaw(url string, data []byte) error {
+	return h.recordRawWithMetadata(url, data, h.metadata.SDKVersion, h.metadata.MachineName, h.metadata.MachineIP)
+}
+
+// recordRawWithMetadata posts data on behalf of the sdk instance identified by the supplied metadata
+func (h *httpRecorderBase) recordRawWithMetadata(
+	url string,
+	data []byte,
+	sdkVersion string,
+	machineName string,
+	machineIP string,
+) error {
 	headers := make(map[string]string)
-	headers["SplitSDKVersion"] = h.metadata.SDKVersion
-	if h.metadata.MachineName != "NA" && h.metadata.MachineName != "unknown" {
-		headers["SplitSDKMachineName"] = h.metadata.MachineName
+	headers["SplitSDKVersion"] = sdkVersion
+	if machineName != "NA" && machineName != "unknown" {
+		headers["SplitSDKMachineName"] = machineName
 	}
-	if h.metadata.MachineIP != "NA" && h.metadata.MachineIP != "unknown" {
-		headers["SplitSDKMachineIP"] = h.metadata.MachineIP
+	if machineIP != "NA" && machineIP != "unknown" {
+		headers["SplitSDKMachineIP"] = machineIP
 	}
-	return h.client.Post(url, data, headers)
+	return h.post(url, data, headers)
 }
 
 // HTTPImpressionRecorder is a struct responsible for submitting impression bulks to the backend
@@ -41,6 +97,7 @@ type impressionRecord struct {
 	ChangeNumber int64  `json:"changeNumber"`
 	Label        string `json:"label"`
 	BucketingKey string `json:"bucketingKey,omitempty"`
+	Pt           int64  `json:"pt,omitempty"`
 }
 
 type impressionsRecord struct {
@@ -48,8 +105,7 @@ type impressionsRecord struct {
 	KeyImpressions []impressionRecord `json:"keyImpressions"`
 }
 
-// Record sends an array (or slice) of impressionsRecord to the backend
-func (i *HTTPImpressionRecorder) Record(impressions []storage.Impression) error {
+func buildImpressionsBulk(impressions []storage.Impression) ([]byte, error) {
 	impressionsToPost := make(map[string][]impressionRecord)
 	for _, impression := range impressions {
 		keyImpression := impressionRecord{
@@ -59,6 +115,7 @@ func (i *HTTPImpressionRecorder) Record(impressions []storage.Impression) error
 			ChangeNumber: impression.ChangeNumber,
 			Label:        impression.Label,
 			BucketingKey: impression.BucketingKey,
+			Pt:           impression.Pt,
 		}
 		v, ok := impressionsToPost[impression.FeatureName]
 		if ok {
@@ -77,7 +134,12 @@ func (i *HTTPImpressionRecorder) Record(impressions []storage.Impression) error
 		})
 	}
 
-	data, err := json.Marshal(bulkImpressions)
+	return json.Marshal(bulkImpressions)
+}
+
+// Record sends an array (or slice) of impressionsRecord to the backend
+func (i *HTTPImpressionRecorder) Record(impressions []storage.Impression) error {
+	data, err := buildImpressionsBulk(impressions)
 	if err != nil {
 		i.logger.Error("Error marshaling JSON", err.Error())
 		return err
@@ -92,6 +154,32 @@ func (i *HTTPImpressionRecorder) Record(impressions []storage.Impression) error
 	return nil
 }
 
+// RecordWithMetadata sends impressions to the backend on behalf of the sdk instance described by metadata
+func (i *HTTPImpressionRecorder) RecordWithMetadata(
+	impressions []storage.Impression,
+	metadata dtos.QueueStoredMachineMetadataDTO,
+) error {
+	data, err := buildImpressionsBulk(impressions)
+	if err != nil {
+		i.logger.Error("Error marshaling JSON", err.Error())
+		return err
+	}
+
+	err = i.recordRawWithMetadata(
+		"/testImpressions/bulk",
+		data,
+		metadata.SDKVersion,
+		metadata.MachineName,
+		metadata.MachineIP,
+	)
+	if err != nil {
+		i.logger.Error("Error posting impressions", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 // NewHTTPImpressionRecorder instantiates an HTTPImpressionRecorder
 func NewHTTPImpressionRecorder(
 	apikey string,
@@ -103,9 +191,51 @@ func NewHTTPImpressionRecorder(
 	client := NewHTTPClient(apikey, cfg, eventsURL, splitio.Version, logger)
 	return &HTTPImpressionRecorder{
 		httpRecorderBase: httpRecorderBase{
-			client:   client,
-			logger:   logger,
-			metadata: metadata,
+			client:               client,
+			logger:               logger,
+			metadata:             metadata,
+			compressionThreshold: cfg.Advanced.CompressionThreshold,
+		},
+	}
+}
+
+// HTTPImpressionsCountRecorder is a struct responsible for submitting impression counts to the backend
+type HTTPImpressionsCountRecorder struct {
+	httpRecorderBase
+}
+
+// Record sends impression counts to the backend
+func (i *HTTPImpressionsCountRecorder) Record(counts dtos.ImpressionsCountDTO) error {
+	data, err := json.Marshal(counts)
+	if err != nil {
+		i.logger.Error("Error marshaling JSON", err.Error())
+		return err
+	}
+
+	err = i.recordRaw("/testImpressions/count", data)
+	if err != nil {
+		i.logger.Error("Error posting impression counts", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// NewHTTPImpressionsCountRecorder instantiates an HTTPImpressionsCountRecorder
+func NewHTTPImpressionsCountRecorder(
+	apikey string,
+	cfg *conf.SplitSdkConfig,
+	metadata *splitio.SdkMetadata,
+	logger logging.LoggerInterface,
+) *HTTPImpressionsCountRecorder {
+	_, eventsURL := getUrls(&cfg.Advanced)
+	client := NewHTTPClient(apikey, cfg, eventsURL, splitio.Version, logger)
+	return &HTTPImpressionsCountRecorder{
+		httpRecorderBase: httpRecorderBase{
+			client:               client,
+			logger:               logger,
+			metadata:             metadata,
+			compressionThreshold: cfg.Advanced.CompressionThreshold,
 		},
 	}
 }
@@ -177,9 +307,10 @@ func NewHTTPMetricsRecorder(
 	client := NewHTTPClient(apikey, cfg, eventsURL, splitio.Version, logger)
 	return &HTTPMetricsRecorder{
 		httpRecorderBase: httpRecorderBase{
-			client:   client,
-			metadata: metadata,
-			logger:   logger,
+			client:               client,
+			metadata:             metadata,
+			logger:               logger,
+			compressionThreshold: cfg.Advanced.CompressionThreshold,
 		},
 	}
 }
@@ -206,6 +337,23 @@ func (i *HTTPEventsRecorder) Record(events []dtos.EventDTO) error {
 	return nil
 }
 
+// RecordWithMetadata sends events to the backend on behalf of the sdk instance described by metadata
+func (i *HTTPEventsRecorder) RecordWithMetadata(events []dtos.EventDTO, metadata dtos.QueueStoredMachineMetadataDTO) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		i.logger.Error("Error marshaling JSON", err.Error())
+		return err
+	}
+
+	err = i.recordRawWithMetadata("/events/bulk", data, metadata.SDKVersion, metadata.MachineName, metadata.MachineIP)
+	if err != nil {
+		i.logger.Error("Error posting events", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 // NewHTTPEventsRecorder instantiates an HTTPEventsRecorder
 func NewHTTPEventsRecorder(
 	apikey string,
@@ -217,9 +365,10 @@ func NewHTTPEventsRecorder(
 	client := NewHTTPClient(apikey, cfg, eventsURL, splitio.Version, logger)
 	return &HTTPEventsRecorder{
 		httpRecorderBase: httpRecorderBase{
-			client:   client,
-			logger:   logger,
-			metadata: metadata,
+			client:               client,
+			logger:               logger,
+			metadata:             metadata,
+			compressionThreshold: cfg.Advanced.CompressionThreshold,
 		},
 	}
 }
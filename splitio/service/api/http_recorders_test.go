@@ -2,8 +2,10 @@
 package api
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -318,3 +320,61 @@ func TestPostMetricsGauge(t *testing.T) {
 	}
 
 }
+
+func TestPostCompressedEvents(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	gzipSupported := true
+	encodings := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, encoding)
+		if encoding == "gzip" && !gzipSupported {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		reader := io.Reader(r.Body)
+		if encoding == "gzip" {
+			gzipReader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error("Body should be gzip compressed", err)
+				return
+			}
+			reader = gzipReader
+		}
+		var events []dtos.EventDTO
+		body, _ := ioutil.ReadAll(reader)
+		if err := json.Unmarshal(body, &events); err != nil || len(events) == 0 {
+			t.Error("Events should be posted", err)
+		}
+	}))
+	defer ts.Close()
+
+	cfg := &conf.SplitSdkConfig{Advanced: conf.AdvancedConfig{EventsURL: ts.URL, SdkURL: ts.URL, CompressionThreshold: 200}}
+	metadata := &splitio.SdkMetadata{SDKVersion: "go-test", MachineName: "machine", MachineIP: "1.2.3.4"}
+	recorder := NewHTTPEventsRecorder("", cfg, metadata, logger)
+
+	small := []dtos.EventDTO{{Key: "k1", EventTypeID: "click", TrafficTypeName: "user"}}
+	large := make([]dtos.EventDTO, 0)
+	for i := 0; i < 10; i++ {
+		large = append(large, dtos.EventDTO{Key: fmt.Sprintf("key%d", i), EventTypeID: "click", TrafficTypeName: "user"})
+	}
+
+	recorder.Record(small)
+	recorder.Record(large)
+	if fmt.Sprint(encodings) != "[ gzip]" {
+		t.Error("Only bulks above the threshold should be compressed. Got: ", encodings)
+	}
+
+	// Servers rejecting compressed bodies get them uncompressed from then on
+	gzipSupported = false
+	encodings = encodings[:0]
+	if err := recorder.Record(large); err != nil {
+		t.Error("Bulk should be posted uncompressed", err)
+	}
+	recorder.Record(large)
+	if fmt.Sprint(encodings) != "[gzip  ]" {
+		t.Error("Compression should be disabled once rejected. Got: ", encodings)
+	}
+}
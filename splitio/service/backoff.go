@@ -0,0 +1,42 @@
+package service
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff struct describes how failed fetches are retried: delays grow exponentially from Base up to Max, and a
+// Jitter fraction (0 to 1) of each delay & sync period is randomized so that sdk instances don't fetch in lockstep
+type Backoff struct {
+	Base         time.Duration
+	Max          time.Duration
+	Jitter       float64
+	InitAttempts int
+}
+
+// Delay returns how long to wait before the next attempt, after attempts consecutive failures
+func (b *Backoff) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := b.Max
+	if attempts <= 32 {
+		if exponential := b.Base << uint(attempts-1); exponential > 0 && exponential < b.Max {
+			delay = exponential
+		}
+	}
+	return delay - b.randomize(delay)
+}
+
+// Spread returns a random offset to be added to a sync period
+func (b *Backoff) Spread(period time.Duration) time.Duration {
+	return b.randomize(period)
+}
+
+func (b *Backoff) randomize(duration time.Duration) time.Duration {
+	window := int64(float64(duration) * b.Jitter)
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(window + 1))
+}
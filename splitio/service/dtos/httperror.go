@@ -0,0 +1,14 @@
+package dtos
+
+import "fmt"
+
+// HTTPError represents a request answered by Split servers with an unsuccessful status code
+type HTTPError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s method: Status Code: %d - %s", e.Method, e.Code, e.Message)
+}
@@ -0,0 +1,13 @@
+package dtos
+
+// ImpressionCountDTO struct mapping the amount of impressions of a feature generated within a time frame
+type ImpressionCountDTO struct {
+	FeatureName string `json:"f"`
+	TimeFrame   int64  `json:"m"`
+	RawCount    int64  `json:"rc"`
+}
+
+// ImpressionsCountDTO struct mapping impression counts post
+type ImpressionsCountDTO struct {
+	PerFeature []ImpressionCountDTO `json:"pf"`
+}
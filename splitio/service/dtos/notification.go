@@ -0,0 +1,22 @@
+package dtos
+
+//
+// Streaming DTOs
+//
+
+// StreamingMessageDTO maps the envelope in which the streaming service wraps every notification
+type StreamingMessageDTO struct {
+	ID        string `json:"id"`
+	Channel   string `json:"channel"`
+	Timestamp int64  `json:"timestamp"`
+	Data      string `json:"data"`
+}
+
+// NotificationDTO maps a split-update, split-kill or segment-update notification
+type NotificationDTO struct {
+	Type             string `json:"type"`
+	ChangeNumber     int64  `json:"changeNumber"`
+	SplitName        string `json:"splitName,omitempty"`
+	DefaultTreatment string `json:"defaultTreatment,omitempty"`
+	SegmentName      string `json:"segmentName,omitempty"`
+}
@@ -15,11 +15,39 @@ type SegmentFetcher interface {
 	Fetch(name string, changeNumber int64) (*dtos.SegmentChangesDTO, error)
 }
 
+// CacheBypassSplitFetcher interface to be implemented by Split Fetchers able to skip cached responses older than
+// a change number
+type CacheBypassSplitFetcher interface {
+	FetchTill(changeNumber int64, till int64) (*dtos.SplitChangesDTO, error)
+}
+
+// CacheBypassSegmentFetcher interface to be implemented by Segment Fetchers able to skip cached responses older
+// than a change number
+type CacheBypassSegmentFetcher interface {
+	FetchTill(name string, changeNumber int64, till int64) (*dtos.SegmentChangesDTO, error)
+}
+
+// BackoffFetcher interface to be implemented by fetchers whose failed requests should be retried after a backoff
+type BackoffFetcher interface {
+	Backoff() *Backoff
+}
+
 // ImpressionsRecorder interface to be implemented by Impressions loggers
 type ImpressionsRecorder interface {
 	Record(impressions []storage.Impression) error
 }
 
+// ImpressionsRecorderWithMetadata interface to be implemented by Impressions loggers able to post impressions
+// on behalf of other sdk instances
+type ImpressionsRecorderWithMetadata interface {
+	RecordWithMetadata(impressions []storage.Impression, metadata dtos.QueueStoredMachineMetadataDTO) error
+}
+
+// ImpressionsCountRecorder interface to be implemented by Impression counts loggers
+type ImpressionsCountRecorder interface {
+	Record(counts dtos.ImpressionsCountDTO) error
+}
+
 // MetricsRecorder interface to be implemented by Metrics loggers
 type MetricsRecorder interface {
 	RecordLatencies(latencies []dtos.LatenciesDTO) error
@@ -31,3 +59,8 @@ type MetricsRecorder interface {
 type EventsRecorder interface {
 	Record(events []dtos.EventDTO) error
 }
+
+// EventsRecorderWithMetadata interface to post events on behalf of other sdk instances
+type EventsRecorderWithMetadata interface {
+	RecordWithMetadata(events []dtos.EventDTO, metadata dtos.QueueStoredMachineMetadataDTO) error
+}
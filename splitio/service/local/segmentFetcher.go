@@ -0,0 +1,119 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+type localSegment struct {
+	keys map[string]bool
+	till int64
+}
+
+// FileSegmentFetcher struct fetches segments from files named after them (<segment>.json) that hold
+// a JSON representation of segment changes
+type FileSegmentFetcher struct {
+	segmentDirectory string
+	segments         map[string]*localSegment
+	logger           logging.LoggerInterface
+	mutex            sync.Mutex
+}
+
+// NewFileSegmentFetcher returns a new instance of FileSegmentFetcher
+func NewFileSegmentFetcher(segmentDirectory string, logger logging.LoggerInterface) *FileSegmentFetcher {
+	return &FileSegmentFetcher{
+		segmentDirectory: segmentDirectory,
+		segments:         make(map[string]*localSegment),
+		logger:           logger,
+	}
+}
+
+func parseSegmentJSON(data []byte) (map[string]bool, int64, error) {
+	var segmentChanges dtos.SegmentChangesDTO
+	err := json.Unmarshal(data, &segmentChanges)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Localhost Parsing: %s", err.Error())
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range segmentChanges.Added {
+		keys[key] = true
+	}
+	for _, key := range segmentChanges.Removed {
+		delete(keys, key)
+	}
+	return keys, segmentChanges.Till, nil
+}
+
+func sameKeys(a map[string]bool, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key := range a {
+		if !b[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetch parses the segment file and returns the changes since the last time it was read
+func (s *FileSegmentFetcher) Fetch(name string, changeNumber int64) (*dtos.SegmentChangesDTO, error) {
+	segmentChanges := &dtos.SegmentChangesDTO{
+		Name:    name,
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Since:   changeNumber,
+		Till:    changeNumber,
+	}
+
+	fileContents, err := ioutil.ReadFile(filepath.Join(s.segmentDirectory, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.logger.Warning(fmt.Sprintf("Localhost mode: file for segment %s not found. It will be considered empty", name))
+			return segmentChanges, nil
+		}
+		return nil, err
+	}
+
+	keys, till, err := parseSegmentJSON(fileContents)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, ok := s.segments[name]
+	if ok && changeNumber == previous.till && sameKeys(keys, previous.keys) {
+		return segmentChanges, nil
+	}
+
+	if ok {
+		till = previous.till + 1
+		for key := range previous.keys {
+			if !keys[key] && changeNumber != -1 {
+				segmentChanges.Removed = append(segmentChanges.Removed, key)
+			}
+		}
+	} else if till <= changeNumber || till <= 0 {
+		till = changeNumber + 1
+		if till <= 0 {
+			till = 1
+		}
+	}
+
+	for key := range keys {
+		segmentChanges.Added = append(segmentChanges.Added, key)
+	}
+	segmentChanges.Till = till
+	s.segments[name] = &localSegment{keys: keys, till: till}
+	return segmentChanges, nil
+}
@@ -1,12 +1,16 @@
 package local
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io/ioutil"
-	"log"
+	"os"
+	"reflect"
 	"regexp"
-	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/splitio/go-client/splitio/engine/evaluator"
 	"github.com/splitio/go-toolkit/logging"
@@ -24,11 +28,20 @@ const (
 	SplitFileFormatYAML
 )
 
-// FileSplitFetcher struct fetches splits from a file
+// FileSplitFetcher struct fetches splits from a file. The file is reloaded only when its contents change, and
+// a new change number is published only when the definitions it holds are different
 type FileSplitFetcher struct {
 	splitFile        string
 	fileFormat       int
 	lastChangeNumber int64
+	lastError        error
+	modTime          time.Time
+	size             int64
+	contentHash      uint64
+	definitions      map[string]dtos.SplitDTO
+	changedAt        map[string]int64
+	listeners        []func(changedSplits []string)
+	mutex            sync.Mutex
 }
 
 // NewFileSplitFetcher returns a new instance of LocalFileSplitFetcher
@@ -38,15 +51,29 @@ func NewFileSplitFetcher(splitFile string, logger logging.LoggerInterface) *File
 		return &FileSplitFetcher{
 			splitFile:  splitFile,
 			fileFormat: SplitFileFormatYAML,
+			changedAt:  make(map[string]int64),
+		}
+	}
+	if IsJSONFile(splitFile) {
+		return &FileSplitFetcher{
+			splitFile:  splitFile,
+			fileFormat: SplitFileFormatJSON,
+			changedAt:  make(map[string]int64),
 		}
 	}
 	logger.Warning("Localhost mode: .split mocks will be deprecated soon in favor of YAML files, which provide more targeting power. Take a look in our documentation.")
 	return &FileSplitFetcher{
 		splitFile:  splitFile,
 		fileFormat: SplitFileFormatClassic,
+		changedAt:  make(map[string]int64),
 	}
 }
 
+// IsJSONFile returns true if the file will be parsed as a JSON representation of split changes
+func IsJSONFile(splitFile string) bool {
+	return strings.HasSuffix(strings.ToLower(splitFile), ".json")
+}
+
 func parseSplitsClassic(data string) []dtos.SplitDTO {
 	splits := make([]dtos.SplitDTO, 0)
 	lines := strings.Split(data, "\n")
@@ -156,104 +183,241 @@ func createCondition(keys interface{}, treatment string) dtos.ConditionDTO {
 	return createRolloutCondition(treatment)
 }
 
-func parseSplitsYAML(data string) (d []dtos.SplitDTO) {
+func parseSplitsYAML(data string) (d []dtos.SplitDTO, problems []ValidationError) {
 	// Set up a guard deferred function to recover if some error occurs during parsing
 	defer func() {
 		if r := recover(); r != nil {
-			// At this point we'll only trust that the logger isn't panicking trust
-			// that the logger isn't panicking
-			log.Fatalf("Localhost Parsing: %v", string(debug.Stack()))
-			d = make([]dtos.SplitDTO, 0)
+			d = nil
+			problems = []ValidationError{{Entry: -1, Problem: fmt.Sprintf("unexpected error: %v", r)}}
 		}
 	}()
 
-	splits := make([]dtos.SplitDTO, 0)
-
-	var splitsFromYAML []map[string]map[string]interface{}
+	var splitsFromYAML []interface{}
 	err := yaml.Unmarshal([]byte(data), &splitsFromYAML)
 	if err != nil {
-		log.Fatalf("error: %v", err)
-		return splits
+		return nil, []ValidationError{{Entry: -1, Problem: err.Error()}}
 	}
 
 	splitsToParse := make(map[string]dtos.SplitDTO, 0)
+	problems = make([]ValidationError, 0)
 
-	for _, splitMap := range splitsFromYAML {
-		for splitName, splitParsed := range splitMap {
-			split, ok := splitsToParse[splitName]
-			treatment, isString := splitParsed["treatment"].(string)
-			if !isString {
-				break
+	for index, entry := range splitsFromYAML {
+		splitName, splitParsed, problem := validateYAMLEntry(entry)
+		if problem != "" {
+			problems = append(problems, ValidationError{Entry: index, Problem: problem})
+			continue
+		}
+
+		split, ok := splitsToParse[splitName]
+		treatment := splitParsed["treatment"].(string)
+		config, isValidConfig := splitParsed["config"].(string)
+		if !ok {
+			configurations := make(map[string]string)
+			if isValidConfig {
+				configurations[treatment] = config
 			}
-			config, isValidConfig := splitParsed["config"].(string)
-			if !ok {
-				configurations := make(map[string]string)
-				if isValidConfig {
-					configurations[treatment] = config
-				}
-				splitsToParse[splitName] = createSplit(
-					splitName,
-					treatment,
-					createCondition(splitParsed["keys"], treatment),
-					configurations,
-				)
+			splitsToParse[splitName] = createSplit(
+				splitName,
+				treatment,
+				createCondition(splitParsed["keys"], treatment),
+				configurations,
+			)
+		} else {
+			newCondition := createCondition(splitParsed["keys"], treatment)
+			if newCondition.ConditionType == "ROLLOUT" {
+				split.Conditions = append(split.Conditions, newCondition)
 			} else {
-				newCondition := createCondition(splitParsed["keys"], treatment)
-				if newCondition.ConditionType == "ROLLOUT" {
-					split.Conditions = append(split.Conditions, newCondition)
-				} else {
-					split.Conditions = append([]dtos.ConditionDTO{newCondition}, split.Conditions...)
-				}
-				configurations := split.Configurations
-				if isValidConfig {
-					configurations[treatment] = config
-				}
-				split.Configurations = configurations
-				splitsToParse[splitName] = split
+				split.Conditions = append([]dtos.ConditionDTO{newCondition}, split.Conditions...)
 			}
-
+			configurations := split.Configurations
+			if isValidConfig {
+				configurations[treatment] = config
+			}
+			split.Configurations = configurations
+			splitsToParse[splitName] = split
 		}
 	}
 
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	splits := make([]dtos.SplitDTO, 0, len(splitsToParse))
 	for _, split := range splitsToParse {
 		splits = append(splits, split)
 	}
 
-	return splits
+	return splits, nil
 }
 
-// Fetch parses the file and returns the appropriate structures
-func (s *FileSplitFetcher) Fetch(changeNumber int64) (*dtos.SplitChangesDTO, error) {
-	fileContents, err := ioutil.ReadFile(s.splitFile)
+func parseSplitsJSON(data []byte) ([]dtos.SplitDTO, []ValidationError) {
+	var splitChanges dtos.SplitChangesDTO
+	err := json.Unmarshal(data, &splitChanges)
 	if err != nil {
-		return nil, err
+		return nil, []ValidationError{{Entry: -1, Problem: err.Error()}}
+	}
+	if splitChanges.Splits == nil {
+		return nil, []ValidationError{{Entry: -1, Problem: "splits not found"}}
 	}
 
-	var splits []dtos.SplitDTO
-	var till int64
-	since := s.lastChangeNumber
-	if s.lastChangeNumber != 0 {
-		//The first time we should return since == till
-		till = since + 1
+	problems := make([]ValidationError, 0)
+	for index, split := range splitChanges.Splits {
+		if split.Name == "" {
+			problems = append(problems, ValidationError{Entry: index, Problem: "split name must be a non-empty string"})
+		}
 	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return splitChanges.Splits, nil
+}
 
-	data := string(fileContents)
+// AddListener registers a function that is called with the names of the splits added, modified or removed
+// each time the file is reloaded. Listeners are called right before the new definitions are returned by Fetch
+func (s *FileSplitFetcher) AddListener(listener func(changedSplits []string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// LastError returns the error of the last fetch, which is a *ValidationReport if the file is not valid.
+// Nil is returned if the last fetch succeeded
+func (s *FileSplitFetcher) LastError() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastError
+}
+
+func (s *FileSplitFetcher) parse(fileContents []byte) ([]dtos.SplitDTO, error) {
+	var splits []dtos.SplitDTO
+	var problems []ValidationError
 	switch s.fileFormat {
 	case SplitFileFormatClassic:
-		splits = parseSplitsClassic(data)
+		splits = parseSplitsClassic(string(fileContents))
 	case SplitFileFormatYAML:
-		splits = parseSplitsYAML(data)
+		splits, problems = parseSplitsYAML(string(fileContents))
 	case SplitFileFormatJSON:
-		return nil, fmt.Errorf("JSON is not yet supported")
+		splits, problems = parseSplitsJSON(fileContents)
 	default:
 		return nil, fmt.Errorf("Unsupported file format")
+	}
 
+	if len(problems) > 0 {
+		return nil, newValidationReport(s.splitFile, problems)
 	}
+	return splits, nil
+}
 
-	s.lastChangeNumber++
-	return &dtos.SplitChangesDTO{
-		Splits: splits,
-		Since:  since,
-		Till:   till,
-	}, nil
+// reload reads the file if it was modified since the last time it was loaded and returns the names of the
+// splits whose definitions changed. Must be called with the mutex held
+func (s *FileSplitFetcher) reload() ([]string, error) {
+	info, err := os.Stat(s.splitFile)
+	if err != nil {
+		return nil, err
+	}
+	if s.definitions != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil, nil
+	}
+
+	fileContents, err := ioutil.ReadFile(s.splitFile)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := fnv.New64a()
+	hasher.Write(fileContents)
+	contentHash := hasher.Sum64()
+	if s.definitions != nil && contentHash == s.contentHash {
+		s.modTime = info.ModTime()
+		s.size = info.Size()
+		return nil, nil
+	}
+
+	splits, err := s.parse(fileContents)
+	if err != nil {
+		// The last valid definitions are kept, since nothing is published
+		return nil, err
+	}
+
+	definitions := make(map[string]dtos.SplitDTO, len(splits))
+	changed := make([]string, 0)
+	for _, split := range splits {
+		definitions[split.Name] = split
+		previous, ok := s.definitions[split.Name]
+		if !ok || !reflect.DeepEqual(previous, split) {
+			changed = append(changed, split.Name)
+		}
+	}
+	for name := range s.definitions {
+		if _, ok := definitions[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+
+	// The first load always publishes a change number, so that the storage is initialized even if the file is empty
+	if len(changed) > 0 || s.lastChangeNumber == 0 {
+		s.lastChangeNumber++
+		for _, name := range changed {
+			s.changedAt[name] = s.lastChangeNumber
+		}
+	}
+
+	s.definitions = definitions
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	s.contentHash = contentHash
+	return changed, nil
+}
+
+// changesSince builds the splits that changed after the supplied change number. Must be called with the mutex held
+func (s *FileSplitFetcher) changesSince(changeNumber int64) *dtos.SplitChangesDTO {
+	changes := &dtos.SplitChangesDTO{
+		Splits: make([]dtos.SplitDTO, 0),
+		Since:  changeNumber,
+		Till:   changeNumber,
+	}
+	if s.lastChangeNumber <= changeNumber {
+		return changes
+	}
+
+	for name, changedAt := range s.changedAt {
+		if changedAt <= changeNumber {
+			continue
+		}
+		split, ok := s.definitions[name]
+		if !ok {
+			if changeNumber == -1 {
+				continue
+			}
+			split = dtos.SplitDTO{Name: name, Status: "ARCHIVED"}
+		}
+		if split.ChangeNumber == 0 {
+			split.ChangeNumber = changedAt
+		}
+		changes.Splits = append(changes.Splits, split)
+	}
+	changes.Till = s.lastChangeNumber
+	return changes
+}
+
+// Fetch reloads the file if its contents changed and returns the splits modified after the supplied change number
+func (s *FileSplitFetcher) Fetch(changeNumber int64) (*dtos.SplitChangesDTO, error) {
+	s.mutex.Lock()
+	firstLoad := s.definitions == nil
+	changed, err := s.reload()
+	s.lastError = err
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+	changes := s.changesSince(changeNumber)
+	listeners := s.listeners
+	s.mutex.Unlock()
+
+	if !firstLoad && len(changed) > 0 {
+		for _, listener := range listeners {
+			listener(changed)
+		}
+	}
+	return changes, nil
 }
@@ -0,0 +1,137 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func writeSplitFile(t *testing.T, name string, contents string) {
+	err := ioutil.WriteFile(name, []byte(contents), 0644)
+	if err != nil {
+		t.Error("Couldn't write split file: ", err)
+	}
+}
+
+func TestYAMLValidationReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_tests")
+	if err != nil {
+		t.Error("Couldn't create temporary directory: ", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := dir + "/splits.yaml"
+	writeSplitFile(t, fileName, "- feature1:\n    treatment: \"on\"\n")
+	fetcher := NewFileSplitFetcher(fileName, logging.NewLogger(&logging.LoggerOptions{}))
+
+	changes, err := fetcher.Fetch(-1)
+	if err != nil || len(changes.Splits) != 1 || fetcher.LastError() != nil {
+		t.Error("Valid file should be parsed", changes, err)
+	}
+
+	writeSplitFile(t, fileName, "- feature1:\n    treatment: \"on\"\n- feature2:\n    keys: \"key\"\n- feature3: \"off\"\n")
+	_, err = fetcher.Fetch(1)
+	report, ok := err.(*ValidationReport)
+	if !ok || fetcher.LastError() != err {
+		t.Error("A validation report should be returned. Got: ", err)
+		return
+	}
+
+	if len(report.Errors) != 2 {
+		t.Error("Every invalid entry should be reported. Got: ", report.Errors)
+		return
+	}
+	if report.Errors[0].File != fileName || report.Errors[0].Entry != 1 || report.Errors[1].Entry != 2 {
+		t.Error("Wrong validation errors: ", report.Errors)
+	}
+
+	writeSplitFile(t, fileName, "- feature1: [\n")
+	_, err = fetcher.Fetch(1)
+	report, ok = err.(*ValidationReport)
+	if !ok || len(report.Errors) != 1 || report.Errors[0].Entry != -1 {
+		t.Error("Malformed YAML should be reported for the whole file. Got: ", err)
+	}
+}
+
+func TestJSONValidationReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_tests")
+	if err != nil {
+		t.Error("Couldn't create temporary directory: ", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := dir + "/splits.json"
+	writeSplitFile(t, fileName, `{"splits":[{"name":"feature1"},{"status":"ACTIVE"}],"since":-1,"till":1}`)
+	fetcher := NewFileSplitFetcher(fileName, logging.NewLogger(&logging.LoggerOptions{}))
+
+	_, err = fetcher.Fetch(-1)
+	report, ok := err.(*ValidationReport)
+	if !ok || len(report.Errors) != 1 || report.Errors[0].Entry != 1 {
+		t.Error("Splits without name should be reported. Got: ", err)
+	}
+}
+
+func TestReloadOnlyOnChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splitio_tests")
+	if err != nil {
+		t.Error("Couldn't create temporary directory: ", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := dir + "/splits.yaml"
+	writeSplitFile(t, fileName, "- feature1:\n    treatment: \"on\"\n- feature2:\n    treatment: \"off\"\n")
+	fetcher := NewFileSplitFetcher(fileName, logging.NewLogger(&logging.LoggerOptions{}))
+
+	var notified []string
+	fetcher.AddListener(func(changedSplits []string) {
+		notified = append(notified, changedSplits...)
+	})
+
+	changes, err := fetcher.Fetch(-1)
+	if err != nil || changes.Since != -1 || changes.Till != 1 || len(changes.Splits) != 2 {
+		t.Error("First fetch should bring every split", changes, err)
+	}
+	if len(notified) != 0 {
+		t.Error("Listeners should not be notified of the initial load")
+	}
+
+	changes, _ = fetcher.Fetch(1)
+	if changes.Since != 1 || changes.Till != 1 || len(changes.Splits) != 0 {
+		t.Error("No changes should be published when the file is untouched", changes)
+	}
+
+	// Same definitions with a different layout
+	writeSplitFile(t, fileName, "- feature2:\n    treatment: \"off\"\n- feature1:\n    treatment: \"on\"\n")
+	changes, _ = fetcher.Fetch(1)
+	if changes.Till != 1 || len(notified) != 0 {
+		t.Error("No changes should be published when definitions are the same", changes)
+	}
+
+	writeSplitFile(t, fileName, "- feature1:\n    treatment: \"off\"\n")
+	changes, _ = fetcher.Fetch(1)
+	if changes.Since != 1 || changes.Till != 2 || len(changes.Splits) != 2 {
+		t.Error("Modified and removed splits should be published", changes)
+		return
+	}
+	for _, split := range changes.Splits {
+		if split.Name == "feature2" && split.Status != "ARCHIVED" {
+			t.Error("Removed split should be archived")
+		}
+		if split.ChangeNumber != 2 {
+			t.Error("Changed splits should carry the new change number")
+		}
+	}
+	if len(notified) != 2 {
+		t.Error("Listeners should be notified of every changed split. Got: ", notified)
+	}
+
+	changes, _ = fetcher.Fetch(-1)
+	if len(changes.Splits) != 1 || changes.Splits[0].Name != "feature1" {
+		t.Error("A full fetch should not bring removed splits", changes)
+	}
+}
@@ -0,0 +1,86 @@
+package local
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a problem found in a split file. Entry is the position of the offending entry
+// within the file, or -1 if the problem affects the whole file
+type ValidationError struct {
+	File    string
+	Entry   int
+	Problem string
+}
+
+func (e ValidationError) Error() string {
+	if e.Entry < 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Problem)
+	}
+	return fmt.Sprintf("%s: entry %d: %s", e.File, e.Entry, e.Problem)
+}
+
+// ValidationReport is returned by Fetch when the split file is not valid. No definitions are taken
+// from a file with problems
+type ValidationReport struct {
+	Errors []ValidationError
+}
+
+func (r *ValidationReport) Error() string {
+	messages := make([]string, 0, len(r.Errors))
+	for _, validationError := range r.Errors {
+		messages = append(messages, validationError.Error())
+	}
+	return "Localhost Parsing: " + strings.Join(messages, "; ")
+}
+
+func newValidationReport(file string, problems []ValidationError) *ValidationReport {
+	for index := range problems {
+		problems[index].File = file
+	}
+	return &ValidationReport{Errors: problems}
+}
+
+// validateYAMLEntry checks that an entry maps a single split name to a valid definition
+func validateYAMLEntry(entry interface{}) (string, map[interface{}]interface{}, string) {
+	entryMap, ok := entry.(map[interface{}]interface{})
+	if !ok || len(entryMap) != 1 {
+		return "", nil, "each entry must map a single split name to its definition"
+	}
+
+	for rawName, rawDefinition := range entryMap {
+		splitName, ok := rawName.(string)
+		if !ok || splitName == "" {
+			return "", nil, "split name must be a non-empty string"
+		}
+
+		definition, ok := rawDefinition.(map[interface{}]interface{})
+		if !ok {
+			return "", nil, fmt.Sprintf("definition of split %s must be a map", splitName)
+		}
+
+		if treatment, ok := definition["treatment"].(string); !ok || treatment == "" {
+			return "", nil, fmt.Sprintf("treatment of split %s must be a non-empty string", splitName)
+		}
+
+		switch keys := definition["keys"].(type) {
+		case nil, string:
+		case []interface{}:
+			for _, key := range keys {
+				if _, ok := key.(string); !ok {
+					return "", nil, fmt.Sprintf("keys of split %s must be strings", splitName)
+				}
+			}
+		default:
+			return "", nil, fmt.Sprintf("keys of split %s must be a string or a list of strings", splitName)
+		}
+
+		if config, ok := definition["config"]; ok && config != nil {
+			if _, ok := config.(string); !ok {
+				return "", nil, fmt.Sprintf("config of split %s must be a string", splitName)
+			}
+		}
+		return splitName, definition, ""
+	}
+	return "", nil, ""
+}
@@ -0,0 +1,492 @@
+// Package splittest provides a local stand-in for Split servers, meant to be used by integration tests that need
+// to run the sdk against a backend without reaching the real one.
+package splittest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+)
+
+// Paths served by the stand-in server
+const (
+	SplitChangesPath     = "/splitChanges"
+	SegmentChangesPath   = "/segmentChanges/"
+	ImpressionsPath      = "/testImpressions/bulk"
+	ImpressionsCountPath = "/testImpressions/count"
+	EventsPath           = "/events/bulk"
+	CountersPath         = "/metrics/counters"
+	LatenciesPath        = "/metrics/times"
+	GaugePath            = "/metrics/gauge"
+)
+
+// Post struct holds a request sent to the server by any of the sdk recorders
+type Post struct {
+	Path     string
+	Metadata dtos.QueueStoredMachineMetadataDTO
+	Body     []byte
+}
+
+type segment struct {
+	keys    map[string]bool
+	changes map[string]int64
+	till    int64
+}
+
+type keyImpression struct {
+	KeyName      string `json:"keyName"`
+	Treatment    string `json:"treatment"`
+	Time         int64  `json:"time"`
+	ChangeNumber int64  `json:"changeNumber"`
+	Label        string `json:"label"`
+	BucketingKey string `json:"bucketingKey"`
+	Pt           int64  `json:"pt"`
+}
+
+type testImpressions struct {
+	TestName       string          `json:"testName"`
+	KeyImpressions []keyImpression `json:"keyImpressions"`
+}
+
+// Server struct serves split & segment definitions kept in memory and records everything posted to it. Definitions
+// can be changed at any time, so that tests can exercise synchronization while the sdk is running
+type Server struct {
+	server    *httptest.Server
+	splits    map[string]dtos.SplitDTO
+	splitTill int64
+	segments  map[string]*segment
+	posts     []Post
+	statuses  map[string]int
+	keys      map[string]int
+	mutex     sync.Mutex
+}
+
+// NewServer instantiates and starts a Server with no definitions. It must be closed once the test is done
+func NewServer() *Server {
+	s := &Server{
+		splits:    make(map[string]dtos.SplitDTO),
+		splitTill: -1,
+		segments:  make(map[string]*segment),
+		posts:     make([]Post, 0),
+		statuses:  make(map[string]int),
+		keys:      make(map[string]int),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the base url of the server
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Configure points the sdk & events urls of the supplied config to the server
+func (s *Server) Configure(cfg *conf.SplitSdkConfig) {
+	cfg.Advanced.SdkURL = s.server.URL
+	cfg.Advanced.EventsURL = s.server.URL
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// SetStatus makes every request to path be answered with the supplied status code. A status of 0 restores the
+// regular behavior. SegmentChangesPath applies to every segment, while SegmentChangesPath followed by a segment name
+// applies to that segment only
+func (s *Server) SetStatus(path string, status int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if status == 0 {
+		delete(s.statuses, path)
+		return
+	}
+	s.statuses[path] = status
+}
+
+// SetAPIKeyStatus makes every request sent with apikey be answered with the supplied status code (ie: 401 for a
+// revoked apikey). A status of 0 restores the regular behavior
+func (s *Server) SetAPIKeyStatus(apikey string, status int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if status == 0 {
+		delete(s.keys, apikey)
+		return
+	}
+	s.keys[apikey] = status
+}
+
+// PutSplits adds or replaces split definitions. Splits without a change number are stamped with the next one
+func (s *Server) PutSplits(splits ...dtos.SplitDTO) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, split := range splits {
+		if split.ChangeNumber <= 0 {
+			split.ChangeNumber = s.splitTill + 1
+		}
+		if split.Status == "" {
+			split.Status = "ACTIVE"
+		}
+		if split.ChangeNumber > s.splitTill {
+			s.splitTill = split.ChangeNumber
+		}
+		s.splits[split.Name] = split
+	}
+}
+
+// KillSplit kills a split, making it return the supplied default treatment
+func (s *Server) KillSplit(name string, defaultTreatment string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	split, ok := s.splits[name]
+	if !ok {
+		return false
+	}
+	s.splitTill++
+	split.Killed = true
+	split.DefaultTreatment = defaultTreatment
+	split.ChangeNumber = s.splitTill
+	s.splits[name] = split
+	return true
+}
+
+// RemoveSplit archives a split so that the sdk removes it on its next synchronization
+func (s *Server) RemoveSplit(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	split, ok := s.splits[name]
+	if !ok {
+		return false
+	}
+	s.splitTill++
+	split.Status = "ARCHIVED"
+	split.ChangeNumber = s.splitTill
+	s.splits[name] = split
+	return true
+}
+
+// UpdateSegment adds and removes keys from a segment, creating it if it doesn't exist
+func (s *Server) UpdateSegment(name string, added []string, removed []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	current, ok := s.segments[name]
+	if !ok {
+		current = &segment{keys: make(map[string]bool), changes: make(map[string]int64), till: -1}
+		s.segments[name] = current
+	}
+	current.till++
+	if current.till == 0 {
+		current.till = 1
+	}
+	for _, key := range added {
+		current.keys[key] = true
+		current.changes[key] = current.till
+	}
+	for _, key := range removed {
+		delete(current.keys, key)
+		current.changes[key] = current.till
+	}
+}
+
+// LoadSplitsFile reads split definitions from a json file, which may hold either a split changes response
+// or a single split
+func (s *Server) LoadSplitsFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var changes dtos.SplitChangesDTO
+	if err = json.Unmarshal(data, &changes); err != nil {
+		return err
+	}
+
+	if len(changes.Splits) == 0 {
+		var split dtos.SplitDTO
+		if err = json.Unmarshal(data, &split); err != nil {
+			return err
+		}
+		if split.Name != "" {
+			changes.Splits = []dtos.SplitDTO{split}
+		}
+	}
+
+	s.PutSplits(changes.Splits...)
+	return nil
+}
+
+// LoadSegmentFile reads a segment changes response from a json file and applies it
+func (s *Server) LoadSegmentFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var changes dtos.SegmentChangesDTO
+	if err = json.Unmarshal(data, &changes); err != nil {
+		return err
+	}
+
+	s.UpdateSegment(changes.Name, changes.Added, changes.Removed)
+	return nil
+}
+
+// Posts returns every request received by the recording endpoints, in arrival order
+func (s *Server) Posts() []Post {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	posts := make([]Post, len(s.posts))
+	copy(posts, s.posts)
+	return posts
+}
+
+// postsTo returns the bodies posted to a path
+func (s *Server) postsTo(path string) [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	bodies := make([][]byte, 0)
+	for _, post := range s.posts {
+		if post.Path == path {
+			bodies = append(bodies, post.Body)
+		}
+	}
+	return bodies
+}
+
+// Impressions returns every impression posted to the server
+func (s *Server) Impressions() []storage.Impression {
+	impressions := make([]storage.Impression, 0)
+	for _, body := range s.postsTo(ImpressionsPath) {
+		var bulk []testImpressions
+		if json.Unmarshal(body, &bulk) != nil {
+			continue
+		}
+		for _, test := range bulk {
+			for _, ki := range test.KeyImpressions {
+				impressions = append(impressions, storage.Impression{
+					FeatureName:  test.TestName,
+					KeyName:      ki.KeyName,
+					BucketingKey: ki.BucketingKey,
+					Treatment:    ki.Treatment,
+					Label:        ki.Label,
+					ChangeNumber: ki.ChangeNumber,
+					Time:         ki.Time,
+					Pt:           ki.Pt,
+				})
+			}
+		}
+	}
+	return impressions
+}
+
+// ImpressionCounts returns every impression count posted to the server
+func (s *Server) ImpressionCounts() []dtos.ImpressionCountDTO {
+	counts := make([]dtos.ImpressionCountDTO, 0)
+	for _, body := range s.postsTo(ImpressionsCountPath) {
+		var posted dtos.ImpressionsCountDTO
+		if json.Unmarshal(body, &posted) == nil {
+			counts = append(counts, posted.PerFeature...)
+		}
+	}
+	return counts
+}
+
+// Events returns every event posted to the server
+func (s *Server) Events() []dtos.EventDTO {
+	events := make([]dtos.EventDTO, 0)
+	for _, body := range s.postsTo(EventsPath) {
+		var posted []dtos.EventDTO
+		if json.Unmarshal(body, &posted) == nil {
+			events = append(events, posted...)
+		}
+	}
+	return events
+}
+
+// Counters returns every counter posted to the server
+func (s *Server) Counters() []dtos.CounterDTO {
+	counters := make([]dtos.CounterDTO, 0)
+	for _, body := range s.postsTo(CountersPath) {
+		var posted []dtos.CounterDTO
+		if json.Unmarshal(body, &posted) == nil {
+			counters = append(counters, posted...)
+		}
+	}
+	return counters
+}
+
+// Latencies returns every latency posted to the server
+func (s *Server) Latencies() []dtos.LatenciesDTO {
+	latencies := make([]dtos.LatenciesDTO, 0)
+	for _, body := range s.postsTo(LatenciesPath) {
+		var posted []dtos.LatenciesDTO
+		if json.Unmarshal(body, &posted) == nil {
+			latencies = append(latencies, posted...)
+		}
+	}
+	return latencies
+}
+
+// Gauges returns every gauge posted to the server
+func (s *Server) Gauges() []dtos.GaugeDTO {
+	gauges := make([]dtos.GaugeDTO, 0)
+	for _, body := range s.postsTo(GaugePath) {
+		var posted dtos.GaugeDTO
+		if json.Unmarshal(body, &posted) == nil {
+			gauges = append(gauges, posted)
+		}
+	}
+	return gauges
+}
+
+// Reset discards every recorded post
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.posts = make([]Post, 0)
+}
+
+func parseSince(r *http.Request) int64 {
+	since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return since
+}
+
+func (s *Server) splitChanges(since int64) dtos.SplitChangesDTO {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	changes := dtos.SplitChangesDTO{Since: since, Till: since, Splits: make([]dtos.SplitDTO, 0)}
+	for _, split := range s.splits {
+		if split.ChangeNumber > since {
+			changes.Splits = append(changes.Splits, split)
+		}
+	}
+	if s.splitTill > since {
+		changes.Till = s.splitTill
+	}
+	return changes
+}
+
+func (s *Server) segmentChanges(name string, since int64) dtos.SegmentChangesDTO {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	changes := dtos.SegmentChangesDTO{
+		Name:    name,
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Since:   since,
+		Till:    since,
+	}
+
+	current, ok := s.segments[name]
+	if !ok || current.till <= since {
+		return changes
+	}
+
+	for key, changeNumber := range current.changes {
+		if changeNumber <= since {
+			continue
+		}
+		if current.keys[key] {
+			changes.Added = append(changes.Added, key)
+		} else if since != -1 {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	if since == -1 {
+		// A full fetch must bring every key, regardless of when it was added
+		changes.Added = changes.Added[:0]
+		for key := range current.keys {
+			changes.Added = append(changes.Added, key)
+		}
+	}
+	changes.Till = current.till
+	return changes
+}
+
+func (s *Server) record(r *http.Request) error {
+	reader := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.posts = append(s.posts, Post{
+		Path: r.URL.Path,
+		Metadata: dtos.QueueStoredMachineMetadataDTO{
+			SDKVersion:  r.Header.Get("SplitSDKVersion"),
+			MachineName: r.Header.Get("SplitSDKMachineName"),
+			MachineIP:   r.Header.Get("SplitSDKMachineIP"),
+		},
+		Body: body,
+	})
+	return nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	status, forced := s.statuses[r.URL.Path]
+	if !forced && strings.HasPrefix(r.URL.Path, SegmentChangesPath) {
+		status, forced = s.statuses[SegmentChangesPath]
+	}
+	if keyStatus, ok := s.keys[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]; ok {
+		status, forced = keyStatus, true
+	}
+	s.mutex.Unlock()
+	if forced {
+		w.WriteHeader(status)
+		return
+	}
+
+	var response interface{}
+	switch {
+	case r.Method == "GET" && r.URL.Path == SplitChangesPath:
+		response = s.splitChanges(parseSince(r))
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, SegmentChangesPath):
+		response = s.segmentChanges(strings.TrimPrefix(r.URL.Path, SegmentChangesPath), parseSince(r))
+	case r.Method == "POST":
+		switch r.URL.Path {
+		case ImpressionsPath, ImpressionsCountPath, EventsPath, CountersPath, LatenciesPath, GaugePath:
+			if err := s.record(r); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	raw, err := json.Marshal(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(raw)
+}
@@ -0,0 +1,174 @@
+package splittest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/splitio/go-client/splitio"
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/api"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func setup() (*Server, *conf.SplitSdkConfig, logging.LoggerInterface) {
+	server := NewServer()
+	cfg := conf.Default()
+	server.Configure(cfg)
+	return server, cfg, logging.NewLogger(&logging.LoggerOptions{})
+}
+
+func TestSplitChanges(t *testing.T) {
+	server, cfg, logger := setup()
+	defer server.Close()
+
+	if err := server.LoadSplitsFile("../../testdata/split_mock.json"); err != nil {
+		t.Error("Split file should be loaded. Got: ", err)
+	}
+
+	fetcher := api.NewHTTPSplitFetcher("someApikey", cfg, logger)
+	changes, err := fetcher.Fetch(-1)
+	if err != nil || len(changes.Splits) != 1 || changes.Till != changes.Splits[0].ChangeNumber {
+		t.Error("Loaded split should be returned", changes, err)
+		return
+	}
+	till := changes.Till
+
+	changes, _ = fetcher.Fetch(till)
+	if len(changes.Splits) != 0 || changes.Since != till || changes.Till != till {
+		t.Error("No changes should be returned once caught up", changes)
+	}
+
+	server.PutSplits(dtos.SplitDTO{Name: "other", DefaultTreatment: "on"})
+	changes, _ = fetcher.Fetch(till)
+	if len(changes.Splits) != 1 || changes.Splits[0].Name != "other" || changes.Till != till+1 {
+		t.Error("Only the new split should be returned", changes)
+	}
+
+	server.KillSplit("other", "off")
+	changes, _ = fetcher.Fetch(till + 1)
+	if len(changes.Splits) != 1 || !changes.Splits[0].Killed || changes.Splits[0].DefaultTreatment != "off" {
+		t.Error("Killed split should be returned", changes)
+	}
+
+	server.RemoveSplit("other")
+	changes, _ = fetcher.Fetch(till + 2)
+	if len(changes.Splits) != 1 || changes.Splits[0].Status != "ARCHIVED" {
+		t.Error("Archived split should be returned", changes)
+	}
+}
+
+func TestSegmentChanges(t *testing.T) {
+	server, cfg, logger := setup()
+	defer server.Close()
+
+	if err := server.LoadSegmentFile("../../testdata/segment_mock.json"); err != nil {
+		t.Error("Segment file should be loaded. Got: ", err)
+	}
+
+	fetcher := api.NewHTTPSegmentFetcher("someApikey", cfg, logger)
+	changes, err := fetcher.Fetch("employees", -1)
+	if err != nil || len(changes.Added) != 1 || changes.Added[0] != "user_for_testing_do_no_erase" {
+		t.Error("Loaded segment should be returned", changes, err)
+		return
+	}
+
+	server.UpdateSegment("employees", []string{"key2"}, []string{"user_for_testing_do_no_erase"})
+	changes, _ = fetcher.Fetch("employees", changes.Till)
+	if len(changes.Added) != 1 || changes.Added[0] != "key2" || len(changes.Removed) != 1 {
+		t.Error("Only the last changes should be returned", changes)
+	}
+
+	changes, _ = fetcher.Fetch("employees", changes.Till)
+	if changes.Since != changes.Till {
+		t.Error("Segment should be caught up", changes)
+	}
+
+	changes, _ = fetcher.Fetch("unknown", -1)
+	if changes.Since != -1 || changes.Till != -1 {
+		t.Error("Unknown segments should be returned empty", changes)
+	}
+}
+
+func TestSegmentChangesStatus(t *testing.T) {
+	server, cfg, logger := setup()
+	defer server.Close()
+	server.UpdateSegment("employees", []string{"key1"}, nil)
+	server.UpdateSegment("admins", []string{"key2"}, nil)
+	fetcher := api.NewHTTPSegmentFetcher("someApikey", cfg, logger)
+
+	server.SetStatus(SegmentChangesPath, http.StatusInternalServerError)
+	_, err := fetcher.Fetch("employees", -1)
+	if httpError, ok := err.(*dtos.HTTPError); !ok || httpError.Code != http.StatusInternalServerError {
+		t.Error("Status should be served for every segment. Got: ", err)
+	}
+
+	server.SetStatus(SegmentChangesPath, 0)
+	server.SetStatus(SegmentChangesPath+"admins", http.StatusNotFound)
+	if _, err = fetcher.Fetch("employees", -1); err != nil {
+		t.Error("Other segments should be served. Got: ", err)
+	}
+	_, err = fetcher.Fetch("admins", -1)
+	if httpError, ok := err.(*dtos.HTTPError); !ok || httpError.Code != http.StatusNotFound {
+		t.Error("Status should be served for the segment. Got: ", err)
+	}
+}
+
+func TestRecorders(t *testing.T) {
+	server, cfg, logger := setup()
+	defer server.Close()
+
+	metadata := &splitio.SdkMetadata{SDKVersion: "go-test", MachineName: "machine", MachineIP: "1.2.3.4"}
+	impressionRecorder := api.NewHTTPImpressionRecorder("someApikey", cfg, metadata, logger)
+	err := impressionRecorder.Record([]storage.Impression{
+		{FeatureName: "f1", KeyName: "k1", Treatment: "on", Time: 1, ChangeNumber: 2, Label: "l"},
+		{FeatureName: "f2", KeyName: "k2", Treatment: "off", Time: 1, ChangeNumber: 2, Label: "l"},
+	})
+	if err != nil || len(server.Impressions()) != 2 {
+		t.Error("Impressions should have been recorded", err, server.Impressions())
+	}
+
+	eventsRecorder := api.NewHTTPEventsRecorder("someApikey", cfg, metadata, logger)
+	eventsRecorder.Record([]dtos.EventDTO{{Key: "k1", EventTypeID: "click"}})
+	if events := server.Events(); len(events) != 1 || events[0].EventTypeID != "click" {
+		t.Error("Events should have been recorded", events)
+	}
+
+	metricsRecorder := api.NewHTTPMetricsRecorder("someApikey", cfg, metadata, logger)
+	metricsRecorder.RecordCounters([]dtos.CounterDTO{{MetricName: "c", Count: 3}})
+	metricsRecorder.RecordLatencies([]dtos.LatenciesDTO{{MetricName: "l", Latencies: []int64{1}}})
+	metricsRecorder.RecordGauge(dtos.GaugeDTO{MetricName: "g", Gauge: 1.5})
+	if len(server.Counters()) != 1 || len(server.Latencies()) != 1 || len(server.Gauges()) != 1 {
+		t.Error("Metrics should have been recorded")
+	}
+
+	countRecorder := api.NewHTTPImpressionsCountRecorder("someApikey", cfg, metadata, logger)
+	countRecorder.Record(dtos.ImpressionsCountDTO{PerFeature: []dtos.ImpressionCountDTO{{FeatureName: "f1", RawCount: 4}}})
+	if counts := server.ImpressionCounts(); len(counts) != 1 || counts[0].RawCount != 4 {
+		t.Error("Impression counts should have been recorded", counts)
+	}
+
+	for _, post := range server.Posts() {
+		if post.Metadata.SDKVersion != "go-test" || post.Metadata.MachineIP != "1.2.3.4" {
+			t.Error("Metadata should have been recorded for ", post.Path)
+		}
+	}
+
+	cfg.Advanced.CompressionThreshold = 1
+	api.NewHTTPEventsRecorder("someApikey", cfg, metadata, logger).Record([]dtos.EventDTO{{Key: "k3", EventTypeID: "view"}})
+	if events := server.Events(); len(events) != 2 || events[1].EventTypeID != "view" {
+		t.Error("Compressed events should have been recorded", events)
+	}
+
+	server.SetStatus(EventsPath, http.StatusInternalServerError)
+	if eventsRecorder.Record([]dtos.EventDTO{{Key: "k2"}}) == nil {
+		t.Error("Forced status should be returned")
+	}
+	server.SetStatus(EventsPath, 0)
+
+	server.Reset()
+	if len(server.Posts()) != 0 {
+		t.Error("Posts should have been discarded")
+	}
+}
@@ -0,0 +1,88 @@
+// Package filelog implements impressions & events storages that write every record to a file as a JSON line,
+// for sdk instances that never post them to Split servers.
+package filelog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+)
+
+// Log appends JSON lines to a file. A Log opened without a path discards every record
+type Log struct {
+	file    *os.File
+	encoder *json.Encoder
+	mutex   sync.Mutex
+}
+
+// Open opens a file for appending, creating it if needed. An empty path returns a Log that discards records
+func Open(path string) (*Log, error) {
+	if path == "" {
+		return &Log{encoder: json.NewEncoder(ioutil.Discard)}, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// write appends a line for each record
+func (l *Log) write(records ...interface{}) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, record := range records {
+		if err := l.encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.file.Close()
+}
+
+// ImpressionsStorage writes impressions to a Log
+type ImpressionsStorage struct {
+	log *Log
+}
+
+// NewImpressionsStorage returns a storage that writes impressions to a log
+func NewImpressionsStorage(log *Log) *ImpressionsStorage {
+	return &ImpressionsStorage{log: log}
+}
+
+// LogImpressions writes impressions
+func (s *ImpressionsStorage) LogImpressions(impressions []storage.Impression) error {
+	records := make([]interface{}, 0, len(impressions))
+	for _, impression := range impressions {
+		records = append(records, impression)
+	}
+	return s.log.write(records...)
+}
+
+// EventsStorage writes events to a Log
+type EventsStorage struct {
+	log *Log
+}
+
+// NewEventsStorage returns a storage that writes events to a log
+func NewEventsStorage(log *Log) *EventsStorage {
+	return &EventsStorage{log: log}
+}
+
+// Push writes an event
+func (s *EventsStorage) Push(event dtos.EventDTO, size int) error {
+	return s.log.write(event)
+}
@@ -0,0 +1,44 @@
+package filelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+)
+
+func TestFileLogs(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filelog")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "records.jsonl")
+
+	log, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewImpressionsStorage(log).LogImpressions([]storage.Impression{
+		{FeatureName: "f1", KeyName: "k1", Treatment: "on"},
+		{FeatureName: "f2", KeyName: "k2", Treatment: "off"},
+	})
+	log.Close()
+
+	// Records are appended to existing files
+	log, _ = Open(path)
+	NewEventsStorage(log).Push(dtos.EventDTO{Key: "k1", EventTypeID: "click"}, 10)
+	log.Close()
+
+	contents, _ := ioutil.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"f":"f2"`) || !strings.Contains(lines[2], `"eventTypeId":"click"`) {
+		t.Error("Unexpected lines", lines)
+	}
+
+	discard, err := Open("")
+	if err != nil || NewEventsStorage(discard).Push(dtos.EventDTO{Key: "k1"}, 10) != nil || discard.Close() != nil {
+		t.Error("Logs without a path should discard records", err)
+	}
+}
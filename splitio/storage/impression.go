@@ -11,6 +11,7 @@ type Impression struct {
 	Label        string `json:"r"`
 	ChangeNumber int64  `json:"c"`
 	Time         int64  `json:"m"`
+	Pt           int64  `json:"pt,omitempty"`
 }
 
 // ImpressionQueueObject struct mapping impressions
@@ -18,3 +19,9 @@ type ImpressionQueueObject struct {
 	Metadata   dtos.QueueStoredMachineMetadataDTO `json:"m"`
 	Impression Impression                         `json:"i"`
 }
+
+// ImpressionsCountKey struct identifies the impressions of a feature generated within a time frame
+type ImpressionsCountKey struct {
+	FeatureName string
+	TimeFrame   int64
+}
@@ -47,6 +47,31 @@ type ImpressionStorageConsumer interface {
 	PopN(n int64) ([]Impression, error)
 }
 
+// ImpressionSpoolConsumer interface should be implemented by durable storages that keep impressions until
+// they're acknowledged. PeekN also returns how many records must be acknowledged to discard the impressions it read.
+// Close is called once the last impressions are posted on shutdown
+type ImpressionSpoolConsumer interface {
+	PeekN(n int64) ([]Impression, int64, error)
+	Ack(n int64) error
+	Close() error
+}
+
+// ImpressionQueueConsumer interface should be implemented by structs that offer popping impressions stored by any
+// sdk instance sharing the storage, along with the metadata of the instance that generated them
+type ImpressionQueueConsumer interface {
+	PopNWithMetadata(n int64) ([]ImpressionQueueObject, error)
+}
+
+// ImpressionsCountStorageProducer interface should be implemented by structs that accept impression counts
+type ImpressionsCountStorageProducer interface {
+	IncCounts(counts map[ImpressionsCountKey]int64)
+}
+
+// ImpressionsCountStorageConsumer interface should be implemented by structs that offer popping impression counts
+type ImpressionsCountStorageConsumer interface {
+	PopCounts() []dtos.ImpressionCountDTO
+}
+
 // MetricsStorageProducer interface should be impemented by structs that accept incoming metrics
 type MetricsStorageProducer interface {
 	PutGauge(key string, gauge float64)
@@ -73,6 +98,22 @@ type EventStorageConsumer interface {
 	Count() int64
 }
 
+// EventSpoolConsumer interface should be implemented by durable storages that keep events until they're
+// acknowledged. PeekN also returns how many records must be acknowledged to discard the events it read. Close is
+// called once the last events are posted on shutdown
+type EventSpoolConsumer interface {
+	PeekN(n int64) ([]dtos.EventDTO, int64, error)
+	Ack(n int64) error
+	Close() error
+}
+
+// EventQueueConsumer interface should be implemented by structs that offer popping events stored by any
+// sdk instance sharing the storage, along with the metadata of the instance that generated them
+type EventQueueConsumer interface {
+	PopNWithMetadata(n int64) ([]dtos.QueueStoredEventDTO, error)
+	Empty() bool
+}
+
 // --- Wide Interfaces
 
 // SplitStorage wraps consumer & producer interfaces
@@ -93,6 +134,12 @@ type ImpressionStorage interface {
 	ImpressionStorageProducer
 }
 
+// ImpressionsCountStorage wraps consumer & producer interfaces
+type ImpressionsCountStorage interface {
+	ImpressionsCountStorageConsumer
+	ImpressionsCountStorageProducer
+}
+
 // MetricsStorage wraps consumer and producer interfaces
 type MetricsStorage interface {
 	MetricsStorageConsumer
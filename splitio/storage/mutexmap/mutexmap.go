@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
 	"github.com/splitio/go-toolkit/datastructures/set"
 )
 
@@ -386,3 +387,47 @@ func (m *MMMetricsStorage) PopLatencies() []dtos.LatenciesDTO {
 	}
 	return latencies
 }
+
+// ** Impressions Count Storage
+
+// MMImpressionsCountStorage contains an in-memory implementation of impression counts storage
+type MMImpressionsCountStorage struct {
+	counts map[storage.ImpressionsCountKey]int64
+	mutex  *sync.Mutex
+}
+
+// NewMMImpressionsCountStorage instantiates a new MMImpressionsCountStorage
+func NewMMImpressionsCountStorage() *MMImpressionsCountStorage {
+	return &MMImpressionsCountStorage{
+		counts: make(map[storage.ImpressionsCountKey]int64),
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// IncCounts adds the received counts to the ones currently stored
+func (m *MMImpressionsCountStorage) IncCounts(counts map[storage.ImpressionsCountKey]int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for key, count := range counts {
+		m.counts[key] += count
+	}
+}
+
+// PopCounts returns and deletes all the impression counts stored
+func (m *MMImpressionsCountStorage) PopCounts() []dtos.ImpressionCountDTO {
+	m.mutex.Lock()
+	defer func() {
+		m.counts = make(map[storage.ImpressionsCountKey]int64)
+		m.mutex.Unlock()
+	}()
+
+	counts := make([]dtos.ImpressionCountDTO, 0, len(m.counts))
+	for key, count := range m.counts {
+		counts = append(counts, dtos.ImpressionCountDTO{
+			FeatureName: key.FeatureName,
+			TimeFrame:   key.TimeFrame,
+			RawCount:    count,
+		})
+	}
+	return counts
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
 	"github.com/splitio/go-toolkit/datastructures/set"
 )
 
@@ -425,3 +426,28 @@ func TestMMSplitStorageObjectLivesAfterDeletion(t *testing.T) {
 		t.Error("Wrong algo")
 	}
 }
+
+func TestImpressionsCountStorage(t *testing.T) {
+	countStorage := NewMMImpressionsCountStorage()
+	countStorage.IncCounts(map[storage.ImpressionsCountKey]int64{
+		{FeatureName: "feature1", TimeFrame: 1}: 2,
+		{FeatureName: "feature2", TimeFrame: 1}: 1,
+	})
+	countStorage.IncCounts(map[storage.ImpressionsCountKey]int64{
+		{FeatureName: "feature1", TimeFrame: 1}: 3,
+	})
+
+	counts := countStorage.PopCounts()
+	if len(counts) != 2 {
+		t.Error("Two counts should have been returned. Got: ", counts)
+	}
+	for _, count := range counts {
+		if count.FeatureName == "feature1" && count.RawCount != 5 {
+			t.Error("Counts should be accumulated. Got: ", count)
+		}
+	}
+
+	if len(countStorage.PopCounts()) != 0 {
+		t.Error("Counts should have been cleared")
+	}
+}
@@ -14,6 +14,7 @@ const (
 	redisImpressionsQueue = "SPLITIO.impressions"                                                // impressions LIST key
 	redisImpressionsTTL   = 60                                                                   // impressions default TTL
 	redisTrafficType      = "SPLITIO.trafficType.{trafficType}"                                  // traffic Type fetch
+	redisImpressionsCount = "SPLITIO.impressions.count"                                          // impression counts HASH key
 )
 
 const (
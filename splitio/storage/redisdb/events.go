@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
 
@@ -33,6 +34,13 @@ func NewRedisEventsStorage(redisClient *PrefixedRedisClient, metadata *splitio.S
 	}
 }
 
+// WithContext returns a copy of the storage whose operations give up once ctx is done
+func (r *RedisEventsStorage) WithContext(ctx context.Context) *RedisEventsStorage {
+	bound := *r
+	bound.client = r.client.WithContext(ctx)
+	return &bound
+}
+
 // Push events into Redis LIST data type with RPUSH command
 func (r *RedisEventsStorage) Push(event dtos.EventDTO, _ int) error {
 
@@ -55,15 +63,14 @@ func (r *RedisEventsStorage) Push(event dtos.EventDTO, _ int) error {
 	return nil
 }
 
-// PopN return N elements from 0 to N
-func (r *RedisEventsStorage) PopN(n int64) ([]dtos.EventDTO, error) {
-	toReturn := make([]dtos.EventDTO, 0)
-
+// popRaw pops up to N raw events from the queue
+func (r *RedisEventsStorage) popRaw(n int64) ([]string, error) {
 	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
 	lrange := r.client.LRange(r.redisKey, 0, n-1)
 	if lrange.Err() != nil {
 		r.logger.Error("Fetching events", lrange.Err().Error())
-		r.mutex.Unlock()
 		return nil, lrange.Err()
 	}
 	totalFetchedEvents := int64(len(lrange.Val()))
@@ -76,13 +83,21 @@ func (r *RedisEventsStorage) PopN(n int64) ([]dtos.EventDTO, error) {
 	res := r.client.LTrim(r.redisKey, idxFrom, -1)
 	if res.Err() != nil {
 		r.logger.Error("Trim events", res.Err().Error())
-		r.mutex.Unlock()
 		return nil, res.Err()
 	}
-	r.mutex.Unlock()
+	return lrange.Val(), nil
+}
+
+// PopN return N elements from 0 to N
+func (r *RedisEventsStorage) PopN(n int64) ([]dtos.EventDTO, error) {
+	toReturn := make([]dtos.EventDTO, 0)
+
+	listOfEvents, err := r.popRaw(n)
+	if err != nil {
+		return nil, err
+	}
 
 	//JSON unmarshal
-	listOfEvents := lrange.Val()
 	for _, se := range listOfEvents {
 		storedEventDTO := dtos.QueueStoredEventDTO{}
 		err := json.Unmarshal([]byte(se), &storedEventDTO)
@@ -100,6 +115,29 @@ func (r *RedisEventsStorage) PopN(n int64) ([]dtos.EventDTO, error) {
 	return toReturn, nil
 }
 
+// PopNWithMetadata return N elements from 0 to N, regardless of the sdk instance that stored them.
+// Each event is returned along with the metadata of the instance that generated it
+func (r *RedisEventsStorage) PopNWithMetadata(n int64) ([]dtos.QueueStoredEventDTO, error) {
+	toReturn := make([]dtos.QueueStoredEventDTO, 0)
+
+	listOfEvents, err := r.popRaw(n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, se := range listOfEvents {
+		storedEventDTO := dtos.QueueStoredEventDTO{}
+		err := json.Unmarshal([]byte(se), &storedEventDTO)
+		if err != nil {
+			r.logger.Error("Error decoding event JSON", err.Error())
+			continue
+		}
+		toReturn = append(toReturn, storedEventDTO)
+	}
+
+	return toReturn, nil
+}
+
 // Count returns the number of items in the redis list
 func (r *RedisEventsStorage) Count() int64 {
 	return r.client.LLen(r.redisKey).Val()
@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
 	"time"
@@ -37,6 +38,13 @@ func NewRedisImpressionStorage(client *PrefixedRedisClient, metadata *splitio.Sd
 	}
 }
 
+// WithContext returns a copy of the storage whose operations give up once ctx is done
+func (r *RedisImpressionStorage) WithContext(ctx context.Context) *RedisImpressionStorage {
+	bound := *r
+	bound.client = r.client.WithContext(ctx)
+	return &bound
+}
+
 // LogImpressions stores impressions in redis as Queue
 func (r *RedisImpressionStorage) LogImpressions(impressions []storage.Impression) error {
 	var impressionsToStore []storage.ImpressionQueueObject
@@ -83,16 +91,14 @@ func (r *RedisImpressionStorage) Push(impressions []storage.ImpressionQueueObjec
 	return nil
 }
 
-// PopN return N elements from 0 to N
-func (r *RedisImpressionStorage) PopN(n int64) ([]storage.Impression, error) {
+// popRaw pops up to N raw impressions from the queue
+func (r *RedisImpressionStorage) popRaw(n int64) ([]string, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	toReturn := make([]storage.Impression, 0)
 
 	lrange := r.client.LRange(r.redisKey, 0, n-1)
 	if lrange.Err() != nil {
 		r.logger.Error("Fetching impressions", lrange.Err().Error())
-		r.mutex.Unlock()
 		return nil, lrange.Err()
 	}
 	totalFetchedImpressions := int64(len(lrange.Val()))
@@ -105,12 +111,21 @@ func (r *RedisImpressionStorage) PopN(n int64) ([]storage.Impression, error) {
 	res := r.client.LTrim(r.redisKey, idxFrom, -1)
 	if res.Err() != nil {
 		r.logger.Error("Trim impressions", res.Err().Error())
-		r.mutex.Unlock()
 		return nil, res.Err()
 	}
+	return lrange.Val(), nil
+}
+
+// PopN return N elements from 0 to N
+func (r *RedisImpressionStorage) PopN(n int64) ([]storage.Impression, error) {
+	toReturn := make([]storage.Impression, 0)
+
+	listOfImpressions, err := r.popRaw(n)
+	if err != nil {
+		return nil, err
+	}
 
 	//JSON unmarshal
-	listOfImpressions := lrange.Val()
 	for _, se := range listOfImpressions {
 		storedImpression := storage.ImpressionQueueObject{}
 		err := json.Unmarshal([]byte(se), &storedImpression)
@@ -127,3 +142,26 @@ func (r *RedisImpressionStorage) PopN(n int64) ([]storage.Impression, error) {
 
 	return toReturn, nil
 }
+
+// PopNWithMetadata return N elements from 0 to N, regardless of the sdk instance that stored them.
+// Each impression is returned along with the metadata of the instance that generated it
+func (r *RedisImpressionStorage) PopNWithMetadata(n int64) ([]storage.ImpressionQueueObject, error) {
+	toReturn := make([]storage.ImpressionQueueObject, 0)
+
+	listOfImpressions, err := r.popRaw(n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, se := range listOfImpressions {
+		storedImpression := storage.ImpressionQueueObject{}
+		err := json.Unmarshal([]byte(se), &storedImpression)
+		if err != nil {
+			r.logger.Error("Error decoding impression JSON", err.Error())
+			continue
+		}
+		toReturn = append(toReturn, storedImpression)
+	}
+
+	return toReturn, nil
+}
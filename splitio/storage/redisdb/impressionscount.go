@@ -0,0 +1,81 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const impressionsCountSeparator = "::"
+
+// RedisImpressionsCountStorage is a redis-based implementation of impression counts storage. Counts are shared
+// by every sdk instance pointing to the same redis, and stored in a hash with one field per feature & time frame
+type RedisImpressionsCountStorage struct {
+	client PrefixedRedisClient
+	logger logging.LoggerInterface
+}
+
+// NewRedisImpressionsCountStorage creates a new RedisImpressionsCountStorage and returns a reference to it
+func NewRedisImpressionsCountStorage(redisClient *PrefixedRedisClient, logger logging.LoggerInterface) *RedisImpressionsCountStorage {
+	return &RedisImpressionsCountStorage{
+		client: *redisClient,
+		logger: logger,
+	}
+}
+
+// WithContext returns a copy of the storage whose operations give up once ctx is done
+func (r *RedisImpressionsCountStorage) WithContext(ctx context.Context) *RedisImpressionsCountStorage {
+	bound := *r
+	bound.client = *r.client.WithContext(ctx)
+	return &bound
+}
+
+// IncCounts adds the received counts to the ones currently stored in redis
+func (r *RedisImpressionsCountStorage) IncCounts(counts map[storage.ImpressionsCountKey]int64) {
+	for key, count := range counts {
+		field := fmt.Sprintf("%s%s%d", key.FeatureName, impressionsCountSeparator, key.TimeFrame)
+		err := r.client.HIncrBy(redisImpressionsCount, field, count)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error incrementing impression count \"%s\" in redis: %s", field, err.Error()))
+		}
+	}
+}
+
+// PopCounts returns and clears all the impression counts stored in redis
+func (r *RedisImpressionsCountStorage) PopCounts() []dtos.ImpressionCountDTO {
+	rawCounts, err := r.client.HGetAllAndDel(redisImpressionsCount)
+	if err != nil {
+		r.logger.Error("Could not retrieve impression counts from redis: ", err.Error())
+		return nil
+	}
+
+	counts := make([]dtos.ImpressionCountDTO, 0, len(rawCounts))
+	for field, rawCount := range rawCounts {
+		separatorIndex := strings.LastIndex(field, impressionsCountSeparator)
+		if separatorIndex < 0 {
+			r.logger.Error(fmt.Sprintf("Ignoring malformed impression count field \"%s\"", field))
+			continue
+		}
+		timeFrame, err := strconv.ParseInt(field[separatorIndex+len(impressionsCountSeparator):], 10, 64)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Ignoring malformed impression count field \"%s\"", field))
+			continue
+		}
+		count, err := strconv.ParseInt(rawCount, 10, 64)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Ignoring malformed impression count \"%s\" for \"%s\"", rawCount, field))
+			continue
+		}
+		counts = append(counts, dtos.ImpressionCountDTO{
+			FeatureName: field[:separatorIndex],
+			TimeFrame:   timeFrame,
+			RawCount:    count,
+		})
+	}
+	return counts
+}
@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"fmt"
 	"github.com/splitio/go-client/splitio"
 	"github.com/splitio/go-client/splitio/service/dtos"
@@ -39,6 +40,13 @@ func NewRedisMetricsStorage(redisClient *PrefixedRedisClient, metadata *splitio.
 	}
 }
 
+// WithContext returns a copy of the storage whose operations give up once ctx is done
+func (r *RedisMetricsStorage) WithContext(ctx context.Context) *RedisMetricsStorage {
+	bound := *r
+	bound.client = *r.client.WithContext(ctx)
+	return &bound
+}
+
 // PutGauge stores a gauge in redis
 func (r *RedisMetricsStorage) PutGauge(key string, gauge float64) {
 	keyToStore := strings.Replace(r.gaugeTemplate, "{metric}", key, 1)
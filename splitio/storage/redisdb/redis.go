@@ -1,8 +1,11 @@
 package redisdb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis"
@@ -77,6 +80,12 @@ func (t *prefixedTx) Get(key string) (string, error) {
 	return res.Val(), res.Err()
 }
 
+// HGetAll wraps redis "hgetall" operation with a prefix inside a transaction
+func (t *prefixedTx) HGetAll(key string) (map[string]string, error) {
+	res := t.tx.HGetAll(t.withPrefix(key))
+	return res.Val(), res.Err()
+}
+
 // newPrefixedPipe instantiates a new pipewrapper and returns a reference
 func newPrefixedTx(tx *redis.Tx, prefix string) *prefixedTx {
 	return &prefixedTx{
@@ -91,42 +100,105 @@ func newPrefixedTx(tx *redis.Tx, prefix string) *prefixedTx {
 // it also uses prefixedPipe for redis trasactions (serialized atomic operations)
 type PrefixedRedisClient struct {
 	prefixable
-	client *redis.Client
+	client  redis.UniversalClient
+	cluster bool
+	ctx     context.Context
 }
 
-// NewPrefixedRedisClient returns a new Prefixed Redis Client
-func NewPrefixedRedisClient(config *conf.RedisConfig) (*PrefixedRedisClient, error) {
-	rClient := redis.NewClient(&redis.Options{
+// newRedisClient builds a cluster, sentinel-backed or single node client depending on the config, along with the
+// prefix for its keys. In cluster mode the prefix starts with the configured hash tag, so that every key is stored
+// in the same slot & multi-key operations are allowed
+func newRedisClient(config *conf.RedisConfig) (redis.UniversalClient, string) {
+	if len(config.ClusterNodes) > 0 {
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     config.ClusterNodes,
+			Password:  config.Password,
+			TLSConfig: config.TLSConfig,
+		}), config.ClusterKeyHashTag + config.Prefix
+	}
+
+	if len(config.SentinelAddresses) > 0 {
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    config.SentinelMaster,
+			SentinelAddrs: config.SentinelAddresses,
+			Password:      config.Password,
+			DB:            config.Database,
+			TLSConfig:     config.TLSConfig,
+		}), config.Prefix
+	}
+
+	return redis.NewClient(&redis.Options{
 		Addr:      fmt.Sprintf("%s:%d", config.Host, config.Port),
 		Password:  config.Password,
 		DB:        config.Database,
 		TLSConfig: config.TLSConfig,
-	})
+	}), config.Prefix
+}
+
+// NewPrefixedRedisClient returns a new Prefixed Redis Client
+func NewPrefixedRedisClient(config *conf.RedisConfig) (*PrefixedRedisClient, error) {
+	if len(config.ClusterNodes) > 0 && config.ClusterKeyHashTag == "" {
+		return nil, errors.New("A key hash tag is required to use a redis cluster")
+	}
 
+	rClient, prefix := newRedisClient(config)
 	err := rClient.Ping().Err()
 	if err != nil {
+		rClient.Close()
 		return nil, err
 	}
 
 	return &PrefixedRedisClient{
 		client:     rClient,
-		prefixable: prefixable{prefix: config.Prefix},
+		cluster:    len(config.ClusterNodes) > 0,
+		prefixable: prefixable{prefix: prefix},
 	}, nil
 }
 
+// WithContext returns a copy of the client bound to ctx, which gives up every operation once ctx is done. Commands
+// already sent are bounded by the client's read & write timeouts
+func (r *PrefixedRedisClient) WithContext(ctx context.Context) *PrefixedRedisClient {
+	bound := *r
+	bound.ctx = ctx
+	switch client := r.client.(type) {
+	case *redis.Client:
+		bound.client = client.WithContext(ctx)
+	case *redis.ClusterClient:
+		bound.client = client.WithContext(ctx)
+	}
+	return &bound
+}
+
+// contextErr returns the error of the context the client is bound to, if it's done
+func (r *PrefixedRedisClient) contextErr() error {
+	if r.ctx == nil {
+		return nil
+	}
+	return r.ctx.Err()
+}
+
 // Get wraps aound redis get method by adding prefix and returning string and error directly
 func (r *PrefixedRedisClient) Get(key string) (string, error) {
+	if err := r.contextErr(); err != nil {
+		return "", err
+	}
 	return r.client.Get(r.withPrefix(key)).Result()
 }
 
 // Set wraps around redis get method by adding prefix and returning error directly
 func (r *PrefixedRedisClient) Set(key string, value interface{}, expiration time.Duration) error {
+	if err := r.contextErr(); err != nil {
+		return err
+	}
 	return r.client.Set(r.withPrefix(key), value, expiration).Err()
 }
 
 // Keys wraps around redis keys method by adding prefix and returning []string and error directly
 func (r *PrefixedRedisClient) Keys(pattern string) ([]string, error) {
-	keys, err := r.client.Keys(r.withPrefix(pattern)).Result()
+	if err := r.contextErr(); err != nil {
+		return nil, err
+	}
+	keys, err := r.keys(r.withPrefix(pattern))
 	if err != nil {
 		return nil, err
 	}
@@ -139,8 +211,33 @@ func (r *PrefixedRedisClient) Keys(pattern string) ([]string, error) {
 
 }
 
+// keys runs the keys command on every master node when connected to a cluster, since it's not routed by slot
+func (r *PrefixedRedisClient) keys(pattern string) ([]string, error) {
+	clusterClient, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return r.client.Keys(pattern).Result()
+	}
+
+	var mutex sync.Mutex
+	keys := make([]string, 0)
+	err := clusterClient.ForEachMaster(func(client *redis.Client) error {
+		nodeKeys, err := client.Keys(pattern).Result()
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		keys = append(keys, nodeKeys...)
+		mutex.Unlock()
+		return nil
+	})
+	return keys, err
+}
+
 // Del wraps around redis del method by adding prefix and returning int64 and error directly
 func (r *PrefixedRedisClient) Del(keys ...string) (int64, error) {
+	if err := r.contextErr(); err != nil {
+		return 0, err
+	}
 	prefixedKeys := make([]string, len(keys))
 	for i, k := range keys {
 		prefixedKeys[i] = r.withPrefix(k)
@@ -150,75 +247,157 @@ func (r *PrefixedRedisClient) Del(keys ...string) (int64, error) {
 
 // SMembers returns a slice with all the members of a set
 func (r *PrefixedRedisClient) SMembers(key string) ([]string, error) {
+	if err := r.contextErr(); err != nil {
+		return nil, err
+	}
 	return r.client.SMembers(r.withPrefix(key)).Result()
 }
 
 // SIsMember returns a slice with all the members of a set
 func (r *PrefixedRedisClient) SIsMember(key string, item interface{}) (bool, error) {
+	if err := r.contextErr(); err != nil {
+		return false, err
+	}
 	return r.client.SIsMember(r.withPrefix(key), item).Result()
 }
 
 // SAdd adds new members to a set
 func (r *PrefixedRedisClient) SAdd(key string, members ...interface{}) (int64, error) {
+	if err := r.contextErr(); err != nil {
+		return 0, err
+	}
 	return r.client.SAdd(r.withPrefix(key), members...).Result()
 }
 
 // SRem removes members from a set
 func (r *PrefixedRedisClient) SRem(key string, members ...string) (int64, error) {
+	if err := r.contextErr(); err != nil {
+		return 0, err
+	}
 	return r.client.SRem(r.withPrefix(key), members).Result()
 }
 
 // Exists returns true if a key exists in redis
 func (r *PrefixedRedisClient) Exists(key string) (bool, error) {
+	if err := r.contextErr(); err != nil {
+		return false, err
+	}
 	val, err := r.client.Exists(r.withPrefix(key)).Result()
 	return (val == 1), err
 }
 
 // Incr increments a key. Sets it in one if it doesn't exist
 func (r *PrefixedRedisClient) Incr(key string) error {
+	if err := r.contextErr(); err != nil {
+		return err
+	}
 	return r.client.Incr(r.withPrefix(key)).Err()
 }
 
+// Decr decrements a key. Sets it in minus one if it doesn't exist
+func (r *PrefixedRedisClient) Decr(key string) error {
+	if err := r.contextErr(); err != nil {
+		return err
+	}
+	return r.client.Decr(r.withPrefix(key)).Err()
+}
+
+// HIncrBy increments the value of a field in a hash. Sets it in value if it doesn't exist
+func (r *PrefixedRedisClient) HIncrBy(key string, field string, value int64) error {
+	if err := r.contextErr(); err != nil {
+		return err
+	}
+	return r.client.HIncrBy(r.withPrefix(key), field, value).Err()
+}
+
+// HGetAllAndDel returns every field of a hash & deletes it within a MULTI/EXEC block, so that no field set in
+// between is lost
+func (r *PrefixedRedisClient) HGetAllAndDel(key string) (map[string]string, error) {
+	if err := r.contextErr(); err != nil {
+		return nil, err
+	}
+	var fields *redis.StringStringMapCmd
+	_, err := r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(r.withPrefix(key))
+		pipe.Del(r.withPrefix(key))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields.Val(), nil
+}
+
 // WrapTransaction accepts a function that performs a set of operations that will
 // be serialized and executed atomically. The function passed will recive a prefixedPipe
 func (r *PrefixedRedisClient) WrapTransaction(f func(t *prefixedTx) error) error {
+	if err := r.contextErr(); err != nil {
+		return err
+	}
+	// Cluster transactions run on the node serving the slot of the watched keys. Watching the hash tag binds them to
+	// the slot every prefixed key is stored in
+	watched := make([]string, 0, 1)
+	if r.cluster {
+		watched = append(watched, r.prefix)
+	}
 	return r.client.Watch(func(tx *redis.Tx) error {
 		return f(newPrefixedTx(tx, r.prefix))
-	})
+	}, watched...)
 }
 
 // RPush insert all the specified values at the tail of the list stored at key
 func (r *PrefixedRedisClient) RPush(key string, values ...interface{}) (int64, error) {
+	if err := r.contextErr(); err != nil {
+		return 0, err
+	}
 	return r.client.RPush(r.withPrefix(key), values...).Result()
 }
 
 // LRange Returns the specified elements of the list stored at key
 func (r *PrefixedRedisClient) LRange(key string, start, stop int64) *redis.StringSliceCmd {
+	if err := r.contextErr(); err != nil {
+		return redis.NewStringSliceResult(nil, err)
+	}
 	return r.client.LRange(r.withPrefix(key), start, stop)
 }
 
 // LTrim Trim an existing list so that it will contain only the specified range of elements specified
 func (r *PrefixedRedisClient) LTrim(key string, start, stop int64) *redis.StatusCmd {
+	if err := r.contextErr(); err != nil {
+		return redis.NewStatusResult("", err)
+	}
 	return r.client.LTrim(r.withPrefix(key), start, stop)
 }
 
 // LLen Returns the length of the list stored at key
 func (r *PrefixedRedisClient) LLen(key string) *redis.IntCmd {
+	if err := r.contextErr(); err != nil {
+		return redis.NewIntResult(0, err)
+	}
 	return r.client.LLen(r.withPrefix(key))
 }
 
 // Expire set expiration time for particular key
 func (r *PrefixedRedisClient) Expire(key string, value time.Duration) *redis.BoolCmd {
+	if err := r.contextErr(); err != nil {
+		return redis.NewBoolResult(false, err)
+	}
 	return r.client.Expire(r.withPrefix(key), value)
 }
 
 // TTL for particular key
 func (r *PrefixedRedisClient) TTL(key string) *redis.DurationCmd {
+	if err := r.contextErr(); err != nil {
+		return redis.NewDurationResult(0, err)
+	}
 	return r.client.TTL(r.withPrefix(key))
 }
 
 // Mget fetchs multiple results
 func (r *PrefixedRedisClient) Mget(keys []string) ([]interface{}, error) {
+	if err := r.contextErr(); err != nil {
+		return nil, err
+	}
 	keysWithPrefix := make([]string, 0)
 	for _, key := range keys {
 		keysWithPrefix = append(keysWithPrefix, r.withPrefix(key))
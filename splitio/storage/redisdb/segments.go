@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"fmt"
 	"github.com/splitio/go-toolkit/datastructures/set"
 	"github.com/splitio/go-toolkit/logging"
@@ -22,6 +23,13 @@ func NewRedisSegmentStorage(redisClient *PrefixedRedisClient, logger logging.Log
 	}
 }
 
+// WithContext returns a copy of the storage whose operations give up once ctx is done
+func (r *RedisSegmentStorage) WithContext(ctx context.Context) *RedisSegmentStorage {
+	bound := *r
+	bound.client = *r.client.WithContext(ctx)
+	return &bound
+}
+
 // Get returns a segment wrapped in a set
 func (r *RedisSegmentStorage) Get(segmentName string) *set.ThreadUnsafeSet {
 	keyToFetch := strings.Replace(redisSegment, "{segment}", segmentName, 1)
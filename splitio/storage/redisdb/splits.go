@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -25,6 +26,13 @@ func NewRedisSplitStorage(redisClient *PrefixedRedisClient, logger logging.Logge
 	}
 }
 
+// WithContext returns a copy of the storage whose operations give up once ctx is done
+func (r *RedisSplitStorage) WithContext(ctx context.Context) *RedisSplitStorage {
+	bound := *r
+	bound.client = r.client.WithContext(ctx)
+	return &bound
+}
+
 // Get fetches a feature in redis and returns a pointer to a split dto
 func (r *RedisSplitStorage) Get(feature string) *dtos.SplitDTO {
 	keyToFetch := strings.Replace(redisSplit, "{split}", feature, 1)
@@ -75,7 +83,39 @@ func (r *RedisSplitStorage) FetchMany(features []string) map[string]*dtos.SplitD
 	return splits
 }
 
-// PutMany bulk stores splits in redis
+// fetchExisting returns the split currently stored with the given name, or nil if there is none.
+// Unlike Get, a missing split is not considered an error
+func (r *RedisSplitStorage) fetchExisting(splitName string) *dtos.SplitDTO {
+	raw, err := r.client.Get(strings.Replace(redisSplit, "{split}", splitName, 1))
+	if err != nil {
+		return nil
+	}
+
+	var split dtos.SplitDTO
+	err = json.Unmarshal([]byte(raw), &split)
+	if err != nil {
+		return nil
+	}
+	return &split
+}
+
+// incrTrafficType increases the count of splits using a traffic type
+func (r *RedisSplitStorage) incrTrafficType(trafficType string) {
+	err := r.client.Incr(strings.Replace(redisTrafficType, "{trafficType}", trafficType, 1))
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Could not increment trafficType \"%s\" in redis: %s", trafficType, err.Error()))
+	}
+}
+
+// decrTrafficType decreases the count of splits using a traffic type
+func (r *RedisSplitStorage) decrTrafficType(trafficType string) {
+	err := r.client.Decr(strings.Replace(redisTrafficType, "{trafficType}", trafficType, 1))
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Could not decrement trafficType \"%s\" in redis: %s", trafficType, err.Error()))
+	}
+}
+
+// PutMany bulk stores splits in redis, keeping traffic type counters up to date
 func (r *RedisSplitStorage) PutMany(splits []dtos.SplitDTO, changeNumber int64) {
 	for _, split := range splits {
 		keyToStore := strings.Replace(redisSplit, "{split}", split.Name, 1)
@@ -85,10 +125,18 @@ func (r *RedisSplitStorage) PutMany(splits []dtos.SplitDTO, changeNumber int64)
 			continue
 		}
 
+		existing := r.fetchExisting(split.Name)
 		err = r.client.Set(keyToStore, raw, 0)
 		if err != nil {
 			r.logger.Error(fmt.Sprintf("Could not store split \"%s\" in redis: %s", split.Name, err.Error()))
+			continue
+		}
+
+		// If it's an update, the traffic type of the previous version is released before counting the new one
+		if existing != nil {
+			r.decrTrafficType(existing.TrafficTypeName)
 		}
+		r.incrTrafficType(split.TrafficTypeName)
 	}
 	err := r.client.Set(redisSplitTill, changeNumber, 0)
 	if err != nil {
@@ -98,10 +146,16 @@ func (r *RedisSplitStorage) PutMany(splits []dtos.SplitDTO, changeNumber int64)
 
 // Remove revemoves a split from redis
 func (r *RedisSplitStorage) Remove(splitName string) {
+	existing := r.fetchExisting(splitName)
 	keyToDelete := strings.Replace(redisSplit, "{split}", splitName, 1)
 	_, err := r.client.Del(keyToDelete)
 	if err != nil {
 		r.logger.Error(fmt.Sprintf("Error deleting split \"%s\".", splitName))
+		return
+	}
+
+	if existing != nil {
+		r.decrTrafficType(existing.TrafficTypeName)
 	}
 }
 
@@ -1,6 +1,7 @@
 package redisdb
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -586,3 +587,123 @@ func TestTrafficTypeStorage(t *testing.T) {
 
 	ttStorage.client.client.Del("testPrefix.SPLITIO.trafficType.mytraffictype")
 }
+
+func TestImpressionsCountStorage(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	prefixedClient, err := NewPrefixedRedisClient(&conf.RedisConfig{
+		Host:     "localhost",
+		Port:     6379,
+		Database: 1,
+		Password: "",
+		Prefix:   "testPrefix",
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	countStorage := NewRedisImpressionsCountStorage(prefixedClient, logger)
+	prefixedClient.Del(redisImpressionsCount)
+
+	countStorage.IncCounts(map[storage.ImpressionsCountKey]int64{
+		{FeatureName: "feature1", TimeFrame: 3600000}: 2,
+		{FeatureName: "feature2", TimeFrame: 3600000}: 1,
+	})
+	countStorage.IncCounts(map[storage.ImpressionsCountKey]int64{
+		{FeatureName: "feature1", TimeFrame: 3600000}: 3,
+	})
+
+	counts := countStorage.PopCounts()
+	if len(counts) != 2 {
+		t.Error("Two counts should have been returned. Got: ", counts)
+	}
+	for _, count := range counts {
+		if count.FeatureName == "feature1" && (count.RawCount != 5 || count.TimeFrame != 3600000) {
+			t.Error("Counts should be accumulated. Got: ", count)
+		}
+	}
+
+	if len(countStorage.PopCounts()) != 0 {
+		t.Error("Counts should have been cleared")
+	}
+}
+
+// slotTag returns the part of a key redis cluster hashes to pick its slot
+func slotTag(key string) string {
+	start := strings.Index(key, "{")
+	if start < 0 {
+		return key
+	}
+	end := strings.Index(key[start+1:], "}")
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+func TestClusterKeyNaming(t *testing.T) {
+	client, prefix := newRedisClient(&conf.RedisConfig{
+		ClusterNodes:      []string{"localhost:7000", "localhost:7001"},
+		ClusterKeyHashTag: "{SPLITIO}",
+		Prefix:            "testPrefix",
+	})
+	defer client.Close()
+
+	prefixed := prefixable{prefix: prefix}
+	keys := []string{
+		strings.Replace(redisSplit, "{split}", "feature{1}", 1),
+		redisSplitTill,
+		redisSegments,
+		strings.Replace(redisSegment, "{segment}", "employees", 1),
+		strings.Replace(redisSegmentTill, "{segment}", "employees", 1),
+		strings.Replace(redisTrafficType, "{trafficType}", "user", 1),
+		redisEvents,
+		redisImpressionsQueue,
+		redisImpressionsCount,
+	}
+	for _, key := range keys {
+		withPrefix := prefixed.withPrefix(key)
+		if slotTag(withPrefix) != "SPLITIO" {
+			t.Error("Key should be hashed by the cluster hash tag. Got: ", withPrefix)
+		}
+		if prefixed.withoutPrefix(withPrefix) != key {
+			t.Error("Prefix should be removed. Got: ", prefixed.withoutPrefix(withPrefix))
+		}
+	}
+
+	client, prefix = newRedisClient(&conf.RedisConfig{Host: "localhost", Port: 6379, Prefix: "testPrefix"})
+	defer client.Close()
+	if prefix != "testPrefix" {
+		t.Error("Prefix should not be altered outside cluster mode. Got: ", prefix)
+	}
+}
+
+func TestContextBoundStorages(t *testing.T) {
+	// Nothing listens on the address, operations should fail without reaching the network
+	client, prefix := newRedisClient(&conf.RedisConfig{Host: "localhost", Port: 1})
+	defer client.Close()
+	prefixedClient := &PrefixedRedisClient{client: client, prefixable: prefixable{prefix: prefix}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bound := prefixedClient.WithContext(ctx)
+	if _, err := bound.Get("some"); err != context.Canceled {
+		t.Error("Operations should give up once the context is done. Got: ", err)
+	}
+	if err := bound.LTrim("some", 0, 1).Err(); err != context.Canceled {
+		t.Error("Operations should give up once the context is done. Got: ", err)
+	}
+	if prefixedClient.contextErr() != nil {
+		t.Error("The original client should not be bound to the context")
+	}
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	metadata := &splitio.SdkMetadata{SDKVersion: "go-test", MachineName: "instance123"}
+	splitStorage := NewRedisSplitStorage(prefixedClient, logger).WithContext(ctx)
+	if splitStorage.Get("feature") != nil {
+		t.Error("No split should be returned once the context is done")
+	}
+	eventsStorage := NewRedisEventsStorage(prefixedClient, metadata, logger).WithContext(ctx)
+	if err := eventsStorage.Push(dtos.EventDTO{Key: "key"}, 0); err != context.Canceled {
+		t.Error("Events should not be pushed once the context is done. Got: ", err)
+	}
+}
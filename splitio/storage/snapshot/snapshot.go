@@ -0,0 +1,125 @@
+// Package snapshot persists the contents of split & segment storages, so that new sdk instances can start from
+// them instead of downloading every split & segment before becoming ready.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/datastructures/set"
+)
+
+// Version of the snapshot format written by this package. Snapshots of other versions are rejected
+const Version = 1
+
+// Segment holds the keys of a segment along with the change number they reflect
+type Segment struct {
+	Name string   `json:"name"`
+	Till int64    `json:"till"`
+	Keys []string `json:"keys"`
+}
+
+// Snapshot holds every split & segment stored at a given time, along with the change numbers they reflect, so that
+// synchronization can resume from them
+type Snapshot struct {
+	Version    int             `json:"version"`
+	SplitsTill int64           `json:"splitsTill"`
+	Splits     []dtos.SplitDTO `json:"splits"`
+	Segments   []Segment       `json:"segments"`
+}
+
+// Take builds a snapshot of the splits & the segments they reference. Change numbers are read before the data, so
+// that changes applied meanwhile are fetched again rather than skipped
+func Take(splitStorage storage.SplitStorage, segmentStorage storage.SegmentStorage) *Snapshot {
+	snapshot := &Snapshot{
+		Version:    Version,
+		SplitsTill: splitStorage.Till(),
+		Splits:     splitStorage.GetAll(),
+		Segments:   make([]Segment, 0),
+	}
+
+	for _, name := range splitStorage.SegmentNames().List() {
+		segmentName, ok := name.(string)
+		if !ok {
+			continue
+		}
+		till := segmentStorage.Till(segmentName)
+		keys := segmentStorage.Get(segmentName)
+		if keys == nil {
+			continue
+		}
+		segment := Segment{Name: segmentName, Till: till, Keys: make([]string, 0, keys.Size())}
+		for _, key := range keys.List() {
+			if strKey, ok := key.(string); ok {
+				segment.Keys = append(segment.Keys, strKey)
+			}
+		}
+		snapshot.Segments = append(snapshot.Segments, segment)
+	}
+	return snapshot
+}
+
+// Parse decodes a snapshot, checking its version
+func Parse(data []byte) (*Snapshot, error) {
+	var snapshot Snapshot
+	err := json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Version != Version {
+		return nil, fmt.Errorf("Unsupported snapshot version %d, expected %d", snapshot.Version, Version)
+	}
+	return &snapshot, nil
+}
+
+// Read reads & decodes the snapshot stored in a file
+func Read(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Write stores the snapshot in a file. It's written to a temporary file first, so that readers never find a partial
+// snapshot
+func (s *Snapshot) Write(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Restore puts the splits & segments of the snapshot in the storages, along with their change numbers
+func (s *Snapshot) Restore(splitStorage storage.SplitStorageProducer, segmentStorage storage.SegmentStorageProducer) {
+	splitStorage.PutMany(s.Splits, s.SplitsTill)
+	for _, segment := range s.Segments {
+		keys := set.NewSet()
+		for _, key := range segment.Keys {
+			keys.Add(key)
+		}
+		segmentStorage.Put(segment.Name, keys, segment.Till)
+	}
+}
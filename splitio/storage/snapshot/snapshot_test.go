@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage/mutexmap"
+	"github.com/splitio/go-toolkit/datastructures/set"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "snapshot")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "splits.snapshot")
+
+	splitStorage := mutexmap.NewMMSplitStorage()
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{{
+		Name:         "feature",
+		ChangeNumber: 10,
+		Conditions: []dtos.ConditionDTO{{
+			MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{
+				MatcherType:        "IN_SEGMENT",
+				UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "employees"},
+			}}},
+		}},
+	}}, 10)
+	segmentStorage.Put("employees", set.NewSet("key1", "key2"), 5)
+
+	if err := Take(splitStorage, segmentStorage).Write(path); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Error("Temporary files should not be left behind", files)
+	}
+
+	stored, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredSplits := mutexmap.NewMMSplitStorage()
+	restoredSegments := mutexmap.NewMMSegmentStorage()
+	stored.Restore(restoredSplits, restoredSegments)
+
+	if restoredSplits.Till() != 10 || restoredSplits.Get("feature") == nil {
+		t.Error("Splits should be restored along with their change number")
+	}
+	if restoredSegments.Till("employees") != 5 {
+		t.Error("Segment change number should be restored. Got: ", restoredSegments.Till("employees"))
+	}
+	if contains, _ := restoredSegments.SegmentContainsKey("employees", "key2"); !contains {
+		t.Error("Segment keys should be restored")
+	}
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	if _, err := Parse([]byte(`{"version": 2, "splitsTill": 1}`)); err == nil {
+		t.Error("Unknown versions should be rejected")
+	}
+	if _, err := Parse([]byte(`{"version": 1`)); err == nil {
+		t.Error("Invalid snapshots should be rejected")
+	}
+	if stored, err := Parse([]byte(`{"version": 1, "splitsTill": 3}`)); err != nil || stored.SplitsTill != 3 {
+		t.Error("Valid snapshots should be parsed", stored, err)
+	}
+}
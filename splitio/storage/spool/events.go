@@ -0,0 +1,79 @@
+package spool
+
+import (
+	"encoding/json"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// EventsStorage struct keeps events in a spool until they're posted
+type EventsStorage struct {
+	spool  *Spool
+	logger logging.LoggerInterface
+}
+
+// NewEventsStorage returns an instance of EventsStorage backed by the supplied spool
+func NewEventsStorage(spool *Spool, logger logging.LoggerInterface) *EventsStorage {
+	return &EventsStorage{spool: spool, logger: logger}
+}
+
+// Push appends an event to the spool
+func (s *EventsStorage) Push(event dtos.EventDTO, size int) error {
+	record, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.spool.Append([][]byte{record})
+}
+
+func (s *EventsStorage) decode(records [][]byte) []dtos.EventDTO {
+	events := make([]dtos.EventDTO, 0, len(records))
+	for _, record := range records {
+		var event dtos.EventDTO
+		if err := json.Unmarshal(record, &event); err != nil {
+			s.logger.Error("Skipping corrupt event found in spool", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// PopN removes up to n events from the spool and returns them
+func (s *EventsStorage) PopN(n int64) ([]dtos.EventDTO, error) {
+	records, err := s.spool.Pop(n)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(records), nil
+}
+
+// PeekN returns up to n events, which are kept in the spool until acknowledged
+func (s *EventsStorage) PeekN(n int64) ([]dtos.EventDTO, int64, error) {
+	records, err := s.spool.Peek(n)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.decode(records), int64(len(records)), nil
+}
+
+// Ack removes the first n events from the spool
+func (s *EventsStorage) Ack(n int64) error {
+	return s.spool.Ack(n)
+}
+
+// Empty returns true if there are no events in the spool
+func (s *EventsStorage) Empty() bool {
+	return s.spool.Count() == 0
+}
+
+// Count returns the number of events in the spool
+func (s *EventsStorage) Count() int64 {
+	return s.spool.Count()
+}
+
+// Close releases the spool. Further writes fail with ErrorClosed
+func (s *EventsStorage) Close() error {
+	return s.spool.Close()
+}
@@ -0,0 +1,83 @@
+package spool
+
+import (
+	"encoding/json"
+
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// ImpressionsStorage struct keeps impressions in a spool until they're posted
+type ImpressionsStorage struct {
+	spool  *Spool
+	logger logging.LoggerInterface
+}
+
+// NewImpressionsStorage returns an instance of ImpressionsStorage backed by the supplied spool
+func NewImpressionsStorage(spool *Spool, logger logging.LoggerInterface) *ImpressionsStorage {
+	return &ImpressionsStorage{spool: spool, logger: logger}
+}
+
+// LogImpressions appends impressions to the spool
+func (s *ImpressionsStorage) LogImpressions(impressions []storage.Impression) error {
+	records := make([][]byte, 0, len(impressions))
+	for _, impression := range impressions {
+		record, err := json.Marshal(impression)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	return s.spool.Append(records)
+}
+
+func (s *ImpressionsStorage) decode(records [][]byte) []storage.Impression {
+	impressions := make([]storage.Impression, 0, len(records))
+	for _, record := range records {
+		var impression storage.Impression
+		if err := json.Unmarshal(record, &impression); err != nil {
+			s.logger.Error("Skipping corrupt impression found in spool", err)
+			continue
+		}
+		impressions = append(impressions, impression)
+	}
+	return impressions
+}
+
+// PopN removes up to n impressions from the spool and returns them
+func (s *ImpressionsStorage) PopN(n int64) ([]storage.Impression, error) {
+	records, err := s.spool.Pop(n)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(records), nil
+}
+
+// PeekN returns up to n impressions, which are kept in the spool until acknowledged
+func (s *ImpressionsStorage) PeekN(n int64) ([]storage.Impression, int64, error) {
+	records, err := s.spool.Peek(n)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.decode(records), int64(len(records)), nil
+}
+
+// Ack removes the first n impressions from the spool
+func (s *ImpressionsStorage) Ack(n int64) error {
+	return s.spool.Ack(n)
+}
+
+// Empty returns true if there are no impressions in the spool
+func (s *ImpressionsStorage) Empty() bool {
+	return s.spool.Count() == 0
+}
+
+// Count returns the number of impressions in the spool
+func (s *ImpressionsStorage) Count() int64 {
+	return s.spool.Count()
+}
+
+// Close releases the spool. Further writes fail with ErrorClosed
+func (s *ImpressionsStorage) Close() error {
+	return s.spool.Close()
+}
@@ -0,0 +1,360 @@
+// Package spool implements disk-backed storages for impressions & events, which keep data until it is posted
+// to Split servers, surviving connectivity losses and process restarts.
+package spool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/splitio/go-client/splitio/conf"
+)
+
+const (
+	segmentExtension = ".spool"
+	cursorFile       = "cursor"
+)
+
+// ErrorMaxSizeReached is returned when a write would make the spool exceed its maximum size
+var ErrorMaxSizeReached = errors.New("Spool max size has been reached")
+
+// ErrorClosed is returned when writing to a closed spool or removing records from it
+var ErrorClosed = errors.New("Spool is closed")
+
+type cursor struct {
+	Segment int64 `json:"segment"`
+	Offset  int64 `json:"offset"`
+}
+
+// Spool struct is a write-ahead queue of records (one per line) split across segment files. Records are read
+// from the position saved in a cursor file, so that acknowledged records are not read again after a restart
+type Spool struct {
+	directory   string
+	maxSize     int64
+	segmentSize int64
+	syncPolicy  string
+	segments    []int64
+	writer      *os.File
+	writerSize  int64
+	totalSize   int64
+	position    cursor
+	count       int64
+	closed      bool
+	mutex       sync.Mutex
+}
+
+// Open opens the spool stored in directory, creating it if it doesn't exist
+func Open(directory string, cfg *conf.SpoolConfig) (*Spool, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spool{
+		directory:   directory,
+		maxSize:     cfg.MaxSize,
+		segmentSize: cfg.SegmentSize,
+		syncPolicy:  cfg.SyncPolicy,
+		segments:    make([]int64, 0),
+	}
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), segmentExtension) {
+			continue
+		}
+		sequence, err := strconv.ParseInt(strings.TrimSuffix(file.Name(), segmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, sequence)
+		s.totalSize += file.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	if err = s.readCursor(); err != nil {
+		return nil, err
+	}
+	if err = s.removeConsumedSegments(); err != nil {
+		return nil, err
+	}
+
+	if len(s.segments) == 0 {
+		// Sequences keep growing so that the saved cursor never points past the new segment
+		sequence := s.position.Segment
+		if sequence < 1 {
+			sequence = 1
+		}
+		s.segments = append(s.segments, sequence)
+		s.position = cursor{Segment: sequence}
+	}
+	if err = s.openWriter(s.segments[len(s.segments)-1]); err != nil {
+		return nil, err
+	}
+
+	records, _, err := s.scan(-1)
+	if err != nil {
+		s.writer.Close()
+		return nil, err
+	}
+	s.count = int64(len(records))
+	return s, nil
+}
+
+func (s *Spool) segmentPath(sequence int64) string {
+	return filepath.Join(s.directory, fmt.Sprintf("%020d%s", sequence, segmentExtension))
+}
+
+func (s *Spool) readCursor() error {
+	data, err := ioutil.ReadFile(filepath.Join(s.directory, cursorFile))
+	if os.IsNotExist(err) {
+		if len(s.segments) > 0 {
+			s.position = cursor{Segment: s.segments[0]}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.position)
+}
+
+// writeCursor saves the read position, replacing the previous one atomically
+func (s *Spool) writeCursor() error {
+	data, _ := json.Marshal(s.position)
+	path := filepath.Join(s.directory, cursorFile)
+	err := ioutil.WriteFile(path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// removeConsumedSegments deletes the segments before the one being read
+func (s *Spool) removeConsumedSegments() error {
+	for len(s.segments) > 1 && s.segments[0] < s.position.Segment {
+		path := s.segmentPath(s.segments[0])
+		info, err := os.Stat(path)
+		if err == nil {
+			s.totalSize -= info.Size()
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		s.segments = s.segments[1:]
+	}
+	return nil
+}
+
+// openWriter opens a segment for appending, discarding any partially written record left by a crash
+func (s *Spool) openWriter(sequence int64) error {
+	file, err := os.OpenFile(s.segmentPath(sequence), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	size := int64(len(data))
+	if complete := int64(strings.LastIndexByte(string(data), '\n') + 1); complete != size {
+		if err = file.Truncate(complete); err != nil {
+			file.Close()
+			return err
+		}
+		s.totalSize -= size - complete
+		size = complete
+	}
+	if _, err = file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+
+	s.writer = file
+	s.writerSize = size
+	return nil
+}
+
+// rotate completes the current segment and starts a new one
+func (s *Spool) rotate() error {
+	if s.syncPolicy != conf.SpoolSyncNever {
+		s.writer.Sync()
+	}
+	s.writer.Close()
+	next := s.segments[len(s.segments)-1] + 1
+	s.segments = append(s.segments, next)
+	return s.openWriter(next)
+}
+
+// Append writes records at the end of the spool. Either every record is written or none is
+func (s *Spool) Append(records [][]byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return ErrorClosed
+	}
+
+	var size int64
+	for _, record := range records {
+		size += int64(len(record) + 1)
+	}
+	if s.totalSize+size > s.maxSize {
+		return ErrorMaxSizeReached
+	}
+
+	for _, record := range records {
+		if s.writerSize > 0 && s.writerSize+int64(len(record)+1) > s.segmentSize {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		written, err := s.writer.Write(append(record, '\n'))
+		s.writerSize += int64(written)
+		s.totalSize += int64(written)
+		if err != nil {
+			return err
+		}
+		s.count++
+	}
+
+	if s.syncPolicy == conf.SpoolSyncAlways {
+		return s.writer.Sync()
+	}
+	return nil
+}
+
+// scan reads up to n records (every record if n < 0) from the read position, returning them along with the
+// position right after the last one. Must be called with the mutex held
+func (s *Spool) scan(n int64) ([][]byte, cursor, error) {
+	records := make([][]byte, 0)
+	position := s.position
+	for _, sequence := range s.segments {
+		if sequence < position.Segment {
+			continue
+		}
+		if sequence > position.Segment {
+			position = cursor{Segment: sequence}
+		}
+
+		file, err := os.Open(s.segmentPath(sequence))
+		if err != nil {
+			return nil, position, err
+		}
+		if _, err = file.Seek(position.Offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, position, err
+		}
+
+		reader := bufio.NewReader(file)
+		for n < 0 || int64(len(records)) < n {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				// Partially written records are not returned
+				break
+			}
+			position.Offset += int64(len(line))
+			records = append(records, line[:len(line)-1])
+		}
+		file.Close()
+
+		if n >= 0 && int64(len(records)) >= n {
+			break
+		}
+	}
+	return records, position, nil
+}
+
+// Peek returns up to n records without removing them from the spool
+func (s *Spool) Peek(n int64) ([][]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	records, _, err := s.scan(n)
+	return records, err
+}
+
+// Ack removes the first n records from the spool, which won't be read again
+func (s *Spool) Ack(n int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ack(n)
+}
+
+func (s *Spool) ack(n int64) error {
+	if s.closed {
+		return ErrorClosed
+	}
+	records, position, err := s.scan(n)
+	if err != nil {
+		return err
+	}
+	return s.advance(position, int64(len(records)))
+}
+
+// advance moves the read position past consumed records and saves it. Must be called with the mutex held
+func (s *Spool) advance(position cursor, consumed int64) error {
+	s.position = position
+	s.count -= consumed
+	if err := s.writeCursor(); err != nil {
+		return err
+	}
+	return s.removeConsumedSegments()
+}
+
+// Pop removes up to n records from the spool and returns them
+func (s *Spool) Pop(n int64) ([][]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil, ErrorClosed
+	}
+	records, position, err := s.scan(n)
+	if err != nil {
+		return nil, err
+	}
+	return records, s.advance(position, int64(len(records)))
+}
+
+// Count returns the amount of records in the spool
+func (s *Spool) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+// Sync flushes written data to disk, unless the sync policy leaves it to the operating system
+func (s *Spool) Sync() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed || s.syncPolicy == conf.SpoolSyncNever {
+		return nil
+	}
+	return s.writer.Sync()
+}
+
+// Close flushes written data to disk and releases the spool files. Closing a closed spool does nothing
+func (s *Spool) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.syncPolicy != conf.SpoolSyncNever {
+		s.writer.Sync()
+	}
+	return s.writer.Close()
+}
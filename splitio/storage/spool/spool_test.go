@@ -0,0 +1,216 @@
+package spool
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func testConfig(segmentSize int64, maxSize int64) *conf.SpoolConfig {
+	return &conf.SpoolConfig{MaxSize: maxSize, SegmentSize: segmentSize, SyncPolicy: conf.SpoolSyncAlways}
+}
+
+func records(from int, to int) [][]byte {
+	result := make([][]byte, 0, to-from)
+	for i := from; i < to; i++ {
+		result = append(result, []byte(fmt.Sprintf("record%02d", i)))
+	}
+	return result
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSpoolAppendPeekAck(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+
+	s, err := Open(dir, testConfig(1024, 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err = s.Append(records(0, 5)); err != nil {
+		t.Error(err)
+	}
+	if s.Count() != 5 {
+		t.Error("Count should be 5. Got: ", s.Count())
+	}
+
+	peeked, _ := s.Peek(3)
+	if len(peeked) != 3 || string(peeked[0]) != "record00" || string(peeked[2]) != "record02" {
+		t.Error("Unexpected peeked records", peeked)
+	}
+	if s.Count() != 5 {
+		t.Error("Peek should not remove records")
+	}
+
+	if err = s.Ack(2); err != nil {
+		t.Error(err)
+	}
+	popped, _ := s.Pop(10)
+	if len(popped) != 3 || string(popped[0]) != "record02" || string(popped[2]) != "record04" {
+		t.Error("Unexpected popped records", popped)
+	}
+	if s.Count() != 0 {
+		t.Error("Spool should be empty")
+	}
+}
+
+func TestSpoolRotationAndRestart(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+
+	// Each record takes 9 bytes, so segments hold 3 records
+	s, err := Open(dir, testConfig(30, 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Append(records(0, 10))
+	if files := segmentFiles(t, dir); len(files) != 4 {
+		t.Error("Records should be split across 4 segments. Got: ", len(files))
+	}
+
+	s.Ack(7)
+	if files := segmentFiles(t, dir); len(files) != 2 {
+		t.Error("Consumed segments should be removed. Got: ", len(files))
+	}
+	s.Close()
+
+	s, err = Open(dir, testConfig(30, 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Count() != 3 {
+		t.Error("Unacknowledged records should survive a restart. Got: ", s.Count())
+	}
+
+	s.Append(records(10, 12))
+	popped, _ := s.Pop(10)
+	if len(popped) != 5 || string(popped[0]) != "record07" || string(popped[4]) != "record11" {
+		t.Error("Unexpected popped records", popped)
+	}
+}
+
+func TestSpoolMaxSize(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+
+	s, _ := Open(dir, testConfig(1024, 30))
+	defer s.Close()
+
+	if err := s.Append(records(0, 3)); err != nil {
+		t.Error(err)
+	}
+	if err := s.Append(records(3, 4)); err != ErrorMaxSizeReached {
+		t.Error("Append beyond max size should fail. Got: ", err)
+	}
+	if s.Count() != 3 {
+		t.Error("Failed appends should not write records. Got: ", s.Count())
+	}
+}
+
+func TestSpoolClose(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+
+	s, _ := Open(dir, testConfig(1024, 4096))
+	s.Append(records(0, 2))
+	if err := s.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Error("Closing a closed spool should do nothing. Got: ", err)
+	}
+	if err := s.Append(records(2, 3)); err != ErrorClosed {
+		t.Error("Appending to a closed spool should fail. Got: ", err)
+	}
+	if err := s.Ack(1); err != ErrorClosed || s.Count() != 2 {
+		t.Error("Acknowledging records of a closed spool should fail. Got: ", err)
+	}
+
+	reopened, _ := Open(dir, testConfig(1024, 4096))
+	defer reopened.Close()
+	if reopened.Count() != 2 {
+		t.Error("Records should be kept after closing. Got: ", reopened.Count())
+	}
+}
+
+func TestSpoolDiscardsPartialRecords(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+
+	s, _ := Open(dir, testConfig(1024, 4096))
+	s.Append(records(0, 2))
+	s.Close()
+
+	// Simulate a crash in the middle of a write
+	file, _ := os.OpenFile(segmentFiles(t, dir)[0], os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("recor")
+	file.Close()
+
+	s, err := Open(dir, testConfig(1024, 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Count() != 2 {
+		t.Error("Partial record should not be counted. Got: ", s.Count())
+	}
+
+	s.Append(records(2, 3))
+	popped, _ := s.Pop(10)
+	if len(popped) != 3 || string(popped[2]) != "record02" {
+		t.Error("Partial record should have been discarded", popped)
+	}
+}
+
+func TestSpooledStorages(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	impressionsSpool, _ := Open(filepath.Join(dir, "impressions"), testConfig(1024, 4096))
+	defer impressionsSpool.Close()
+	impressions := NewImpressionsStorage(impressionsSpool, logger)
+	impressions.LogImpressions([]storage.Impression{
+		{FeatureName: "f1", BucketingKey: "b1", ChangeNumber: 1, KeyName: "k1", Label: "l1", Treatment: "on", Time: 1},
+		{FeatureName: "f2", BucketingKey: "b2", ChangeNumber: 2, KeyName: "k2", Label: "l2", Treatment: "off", Time: 2},
+	})
+
+	peeked, count, err := impressions.PeekN(10)
+	if err != nil || count != 2 || len(peeked) != 2 || peeked[1].FeatureName != "f2" || peeked[1].Treatment != "off" {
+		t.Error("Unexpected peeked impressions", peeked, count, err)
+	}
+	impressions.Ack(count)
+	if !impressions.Empty() {
+		t.Error("Acknowledged impressions should be removed")
+	}
+
+	eventsSpool, _ := Open(filepath.Join(dir, "events"), testConfig(1024, 4096))
+	defer eventsSpool.Close()
+	events := NewEventsStorage(eventsSpool, logger)
+	events.Push(dtos.EventDTO{EventTypeID: "ET0", Key: "K0", Timestamp: 0, TrafficTypeName: "TTN0", Value: 0.5}, 100)
+
+	popped, err := events.PopN(10)
+	if err != nil || len(popped) != 1 || popped[0].EventTypeID != "ET0" || popped[0].Value != 0.5 {
+		t.Error("Unexpected popped events", popped, err)
+	}
+	if events.Count() != 0 {
+		t.Error("Popped events should be removed")
+	}
+}
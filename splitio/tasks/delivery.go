@@ -0,0 +1,192 @@
+package tasks
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+const (
+	// defaultRetryBudget is the maximum amount of items (impressions, events, metrics) held for retrying by each task
+	defaultRetryBudget = 30000
+
+	// defaultMaxAttempts is the amount of times a bulk is posted before giving up on it
+	defaultMaxAttempts = 6
+
+	baseRetryBackoff = 5 * time.Second
+	maxRetryBackoff  = 10 * time.Minute
+)
+
+type heldBulk struct {
+	post        func() error
+	size        int
+	attempts    int
+	nextAttempt time.Time
+}
+
+// delivery posts bulks on behalf of a recording task. Bulks that fail with retryable errors are held and posted
+// again once their backoff elapses, while those that fail with permanent errors are dropped. Held bulks are bounded
+// by an item budget, beyond which the oldest ones are dropped
+type delivery struct {
+	name        string
+	budget      int
+	maxAttempts int
+	held        []*heldBulk
+	heldItems   int
+	dropped     int64
+	status      *TaskStatus
+	logger      logging.LoggerInterface
+	mutex       sync.Mutex
+}
+
+func newDelivery(
+	name string,
+	budget int,
+	maxAttempts int,
+	logger logging.LoggerInterface,
+	status *TaskStatus,
+) *delivery {
+	return &delivery{
+		name:        name,
+		budget:      budget,
+		maxAttempts: maxAttempts,
+		held:        make([]*heldBulk, 0),
+		status:      status,
+		logger:      logger,
+	}
+}
+
+// isRetryable returns false for requests rejected by the server because of their contents, which won't succeed
+// no matter how many times they're posted. Network errors, throttling and server errors can be retried
+func isRetryable(err error) bool {
+	httpError, ok := err.(*dtos.HTTPError)
+	if !ok {
+		return true
+	}
+	return httpError.Code >= 500 || httpError.Code == 408 || httpError.Code == 429
+}
+
+// retryBackoff returns how long to wait before the next attempt: an exponential delay with jitter over its upper
+// half, so that sdk instances failing at the same time don't retry in lockstep
+func retryBackoff(attempts int) time.Duration {
+	delay := baseRetryBackoff << uint(attempts-1)
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// send posts a bulk of size items, holding it for retrying if it fails with a retryable error
+func (d *delivery) send(size int, post func() error) error {
+	err := post()
+	if err != nil {
+		d.mutex.Lock()
+		d.fail(&heldBulk{post: post, size: size}, err, false)
+		d.mutex.Unlock()
+	}
+	return err
+}
+
+// sendSpooled posts a bulk read from a durable storage, acknowledging it unless it fails with a retryable error.
+// Bulks that aren't acknowledged stay in the storage, and are read again on the next run
+func (d *delivery) sendSpooled(records int64, post func() error, ack func(n int64) error) error {
+	err := post()
+	if err != nil && isRetryable(err) {
+		return err
+	}
+	if err != nil {
+		d.mutex.Lock()
+		d.drop(&heldBulk{size: int(records)}, "permanent error: "+err.Error())
+		d.mutex.Unlock()
+	}
+	if ackErr := ack(records); ackErr != nil {
+		d.logger.Error(d.name+": error acknowledging spooled items", ackErr)
+		if err == nil {
+			err = ackErr
+		}
+	}
+	return err
+}
+
+// fail holds or drops a bulk after a failed attempt. Must be called with the mutex held
+func (d *delivery) fail(bulk *heldBulk, err error, final bool) {
+	bulk.attempts++
+	switch {
+	case !isRetryable(err):
+		d.drop(bulk, "permanent error: "+err.Error())
+		return
+	case final:
+		d.drop(bulk, "task stopped: "+err.Error())
+		return
+	case bulk.attempts >= d.maxAttempts:
+		d.drop(bulk, fmt.Sprintf("failed %d times: %s", bulk.attempts, err.Error()))
+		return
+	}
+
+	bulk.nextAttempt = time.Now().Add(retryBackoff(bulk.attempts))
+	d.held = append(d.held, bulk)
+	d.heldItems += bulk.size
+	for d.heldItems > d.budget && len(d.held) > 0 {
+		oldest := d.held[0]
+		d.held = d.held[1:]
+		d.heldItems -= oldest.size
+		d.drop(oldest, "retry budget exceeded")
+	}
+	d.status.recordDelivery(d.heldItems, d.dropped)
+}
+
+// drop discards a bulk. Must be called with the mutex held
+func (d *delivery) drop(bulk *heldBulk, reason string) {
+	d.dropped += int64(bulk.size)
+	d.logger.Warning(fmt.Sprintf(
+		"%s: %d items dropped (%s). %d items dropped so far",
+		d.name,
+		bulk.size,
+		reason,
+		d.dropped,
+	))
+	d.status.recordDelivery(d.heldItems, d.dropped)
+}
+
+// attempt posts again the held bulks whose backoff elapsed, or every one of them if final is set. Bulks failing
+// on a final attempt are dropped
+func (d *delivery) attempt(final bool) {
+	d.mutex.Lock()
+	now := time.Now()
+	due := make([]*heldBulk, 0)
+	remaining := make([]*heldBulk, 0, len(d.held))
+	d.heldItems = 0
+	for _, bulk := range d.held {
+		if final || !now.Before(bulk.nextAttempt) {
+			due = append(due, bulk)
+		} else {
+			remaining = append(remaining, bulk)
+			d.heldItems += bulk.size
+		}
+	}
+	d.held = remaining
+	d.status.recordDelivery(d.heldItems, d.dropped)
+	d.mutex.Unlock()
+
+	for _, bulk := range due {
+		if err := bulk.post(); err != nil {
+			d.mutex.Lock()
+			d.fail(bulk, err, final)
+			d.mutex.Unlock()
+		}
+	}
+}
+
+// retry posts again the held bulks whose backoff elapsed
+func (d *delivery) retry() {
+	d.attempt(false)
+}
+
+// flush posts every held bulk once, dropping those that fail
+func (d *delivery) flush() {
+	d.attempt(true)
+}
@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestDeliveryRetriesRetryableErrors(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	status := NewTaskStatus()
+	d := newDelivery("test", 100, 3, logger, status)
+
+	attempts := 0
+	post := func() error {
+		attempts++
+		if attempts < 3 {
+			return &dtos.HTTPError{Method: "POST", Code: 503, Message: "unavailable"}
+		}
+		return nil
+	}
+
+	if d.send(10, post) == nil {
+		t.Error("The error of the first attempt should be returned")
+	}
+	if status.Get().HeldItems != 10 {
+		t.Error("Failed bulk should be held. Got: ", status.Get().HeldItems)
+	}
+
+	d.retry()
+	if attempts != 1 {
+		t.Error("Bulk should not be retried before its backoff elapses")
+	}
+
+	// Make the held bulk due
+	d.held[0].nextAttempt = time.Now()
+	d.retry()
+	if attempts != 2 || status.Get().HeldItems != 10 {
+		t.Error("Bulk should have been retried and held again", attempts, status.Get().HeldItems)
+	}
+
+	d.flush()
+	if attempts != 3 || status.Get().HeldItems != 0 || status.Get().DroppedItems != 0 {
+		t.Error("Bulk should have been posted on flush", attempts, status.Get().HeldItems, status.Get().DroppedItems)
+	}
+}
+
+func TestDeliveryDropsPermanentErrors(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	status := NewTaskStatus()
+	d := newDelivery("test", 100, 3, logger, status)
+
+	d.send(5, func() error { return &dtos.HTTPError{Method: "POST", Code: 400, Message: "bad request"} })
+	if status.Get().HeldItems != 0 || status.Get().DroppedItems != 5 {
+		t.Error("Bulks rejected by the server should be dropped")
+	}
+
+	d.send(5, func() error { return &dtos.HTTPError{Method: "POST", Code: 429, Message: "too many requests"} })
+	if status.Get().HeldItems != 5 {
+		t.Error("Throttled bulks should be held")
+	}
+}
+
+func TestDeliveryLimits(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	status := NewTaskStatus()
+	d := newDelivery("test", 25, 2, logger, status)
+
+	networkError := errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		d.send(10, func() error { return networkError })
+	}
+	if status.Get().HeldItems != 20 || status.Get().DroppedItems != 10 {
+		t.Error("The oldest bulk should be dropped once the budget is exceeded", status.Get().HeldItems, status.Get().DroppedItems)
+	}
+
+	for _, bulk := range d.held {
+		bulk.nextAttempt = time.Now()
+	}
+	d.retry()
+	if status.Get().HeldItems != 0 || status.Get().DroppedItems != 30 {
+		t.Error("Bulks should be dropped after the maximum attempts", status.Get().HeldItems, status.Get().DroppedItems)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for attempts := 1; attempts < 100; attempts++ {
+		backoff := retryBackoff(attempts)
+		if backoff <= 0 || backoff > maxRetryBackoff {
+			t.Error("Backoff out of bounds: ", backoff)
+		}
+	}
+	if retryBackoff(1) > baseRetryBackoff {
+		t.Error("First backoff should not exceed the base one")
+	}
+}
@@ -14,7 +14,15 @@ func submitEvents(
 	eventRecorder service.EventsRecorder,
 	bulkSize int64,
 	logger logging.LoggerInterface,
+	delivery *delivery,
 ) error {
+	if spooled, ok := eventStorage.(storage.EventSpoolConsumer); ok {
+		return submitSpooledEvents(spooled, eventRecorder, bulkSize, logger, delivery)
+	}
+
+	// Bulks that failed before go first, provided their backoff has elapsed
+	delivery.retry()
+
 	queuedEvents, err := eventStorage.PopN(bulkSize)
 	if err != nil {
 		logger.Error("Error reading events queue", err)
@@ -26,7 +34,36 @@ func submitEvents(
 		return nil
 	}
 
-	return eventRecorder.Record(queuedEvents)
+	return delivery.send(len(queuedEvents), func() error {
+		return eventRecorder.Record(queuedEvents)
+	})
+}
+
+// submitSpooledEvents posts events read from a durable storage, which keeps them until they're posted
+func submitSpooledEvents(
+	eventStorage storage.EventSpoolConsumer,
+	eventRecorder service.EventsRecorder,
+	bulkSize int64,
+	logger logging.LoggerInterface,
+	delivery *delivery,
+) error {
+	spooledEvents, records, err := eventStorage.PeekN(bulkSize)
+	if err != nil {
+		logger.Error("Error reading events spool", err)
+		return errors.New("Error reading events spool")
+	}
+
+	if records == 0 {
+		logger.Debug("No events fetched from spool. Nothing to send")
+		return nil
+	}
+
+	return delivery.sendSpooled(records, func() error {
+		if len(spooledEvents) == 0 {
+			return nil
+		}
+		return eventRecorder.Record(spooledEvents)
+	}, eventStorage.Ack)
 }
 
 func onStopAction(
@@ -34,17 +71,30 @@ func onStopAction(
 	eventRecorder service.EventsRecorder,
 	bulkSize int64,
 	logger logging.LoggerInterface,
+	delivery *delivery,
 ) {
 
+	spool, spooled := eventStorage.(storage.EventSpoolConsumer)
 	for !eventStorage.Empty() {
-		submitEvents(
+		err := submitEvents(
 			eventStorage,
 			eventRecorder,
 			bulkSize,
 			logger,
+			delivery,
 		)
+		if err != nil && spooled {
+			// Events are kept in the spool, and will be posted once the sdk starts again
+			break
+		}
 	}
+	delivery.flush()
 
+	if spooled {
+		if err := spool.Close(); err != nil {
+			logger.Error("Error closing events spool", err)
+		}
+	}
 }
 
 // NewRecordEventsTask creates a new events recording task
@@ -54,15 +104,19 @@ func NewRecordEventsTask(
 	bulkSize int64,
 	period int,
 	logger logging.LoggerInterface,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitEvents", defaultRetryBudget, defaultMaxAttempts, logger, status)
 	record := func(logger logging.LoggerInterface) error {
-		return submitEvents(eventStorage, eventRecorder, bulkSize, logger)
+		err := submitEvents(eventStorage, eventRecorder, bulkSize, logger, delivery)
+		status.Record(err)
+		return err
 	}
 
 	onStop := func(logger logging.LoggerInterface) {
 		// All this function does is flush events which will clear the storage
 		//record(logger)
-		onStopAction(eventStorage, eventRecorder, bulkSize, logger)
+		onStopAction(eventStorage, eventRecorder, bulkSize, logger, delivery)
 	}
 
 	return asynctask.NewAsyncTask("SubmitEvents", record, period, nil, onStop, logger)
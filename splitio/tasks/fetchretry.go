@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-toolkit/asynctask"
+)
+
+// fetchRetrier spreads the periodic runs of a fetching task over a random fraction of its period, and wakes the
+// task up early to retry failed fetches once their backoff elapses
+type fetchRetrier struct {
+	backoff  *service.Backoff
+	period   time.Duration
+	task     *asynctask.AsyncTask
+	failures int
+	retrying bool
+	mutex    sync.Mutex
+}
+
+func newFetchRetrier(backoff *service.Backoff, period int, task *asynctask.AsyncTask) *fetchRetrier {
+	return &fetchRetrier{
+		backoff: backoff,
+		period:  time.Duration(period) * time.Second,
+		task:    task,
+	}
+}
+
+// wait sleeps for a random spread before periodic runs. Retries are not delayed
+func (r *fetchRetrier) wait() {
+	if r == nil || r.backoff == nil {
+		return
+	}
+	r.mutex.Lock()
+	retrying := r.retrying
+	r.retrying = false
+	r.mutex.Unlock()
+	if !retrying {
+		time.Sleep(r.backoff.Spread(r.period))
+	}
+}
+
+// done records the outcome of a run, scheduling a retry if it failed and the retry is due before the next
+// periodic run
+func (r *fetchRetrier) done(err error) {
+	if r == nil || r.backoff == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err == nil || !isRetryable(err) {
+		r.failures = 0
+		return
+	}
+
+	r.failures++
+	delay := r.backoff.Delay(r.failures)
+	if delay >= r.period {
+		return
+	}
+	r.retrying = true
+	time.AfterFunc(delay, func() { r.task.WakeUp() })
+}
@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage/mutexmap"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+type backoffSplitFetcher struct {
+	errors  []error
+	fetches int
+	backoff *service.Backoff
+	mutex   sync.Mutex
+}
+
+func (f *backoffSplitFetcher) Fetch(changeNumber int64) (*dtos.SplitChangesDTO, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.fetches++
+	if len(f.errors) > 0 {
+		err := f.errors[0]
+		f.errors = f.errors[1:]
+		return nil, err
+	}
+	return &dtos.SplitChangesDTO{Since: 1, Till: 1, Splits: []dtos.SplitDTO{}}, nil
+}
+
+func (f *backoffSplitFetcher) Backoff() *service.Backoff {
+	return f.backoff
+}
+
+func (f *backoffSplitFetcher) pendingErrors() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.errors)
+}
+
+func TestSplitsInitRetriesWithBackoff(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	unavailable := &dtos.HTTPError{Method: "GET", Code: 503, Message: "unavailable"}
+	fetcher := &backoffSplitFetcher{
+		errors:  []error{unavailable, errors.New("connection refused")},
+		backoff: &service.Backoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond, InitAttempts: 3},
+	}
+
+	readyChannel := make(chan string, 1)
+	task := NewFetchSplitsTask(mutexmap.NewMMSplitStorage(), fetcher, 60, logger, readyChannel, false, nil, nil)
+	task.Start()
+	defer task.Stop()
+
+	if msg := <-readyChannel; msg != "SPLITS_READY" {
+		t.Error("Splits should be ready after retrying. Got: ", msg)
+	}
+	if fetcher.pendingErrors() != 0 {
+		t.Error("Every failed fetch should have been retried")
+	}
+}
+
+func TestSplitsInitGivesUp(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	unavailable := &dtos.HTTPError{Method: "GET", Code: 503, Message: "unavailable"}
+	fetcher := &backoffSplitFetcher{
+		errors:  []error{unavailable, unavailable, unavailable},
+		backoff: &service.Backoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond, InitAttempts: 2},
+	}
+
+	readyChannel := make(chan string, 1)
+	task := NewFetchSplitsTask(mutexmap.NewMMSplitStorage(), fetcher, 60, logger, readyChannel, false, nil, nil)
+	task.Start()
+	if msg := <-readyChannel; msg != "SPLITS_ERROR" || fetcher.fetches != 2 {
+		t.Error("Splits initialization should fail after 2 attempts", msg, fetcher.fetches)
+	}
+
+	// Requests rejected because of the apikey are not retried
+	fetcher = &backoffSplitFetcher{
+		errors:  []error{&dtos.HTTPError{Method: "GET", Code: 401, Message: "unauthorized"}},
+		backoff: &service.Backoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond, InitAttempts: 5},
+	}
+	task = NewFetchSplitsTask(mutexmap.NewMMSplitStorage(), fetcher, 60, logger, readyChannel, false, nil, nil)
+	task.Start()
+	if msg := <-readyChannel; msg != "SPLITS_ERROR" || fetcher.fetches != 1 {
+		t.Error("Splits initialization should fail right away", msg, fetcher.fetches)
+	}
+}
+
+func TestBackoffDelays(t *testing.T) {
+	backoff := &service.Backoff{Base: time.Second, Max: 10 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for index, delay := range expected {
+		if backoff.Delay(index+1) != delay {
+			t.Errorf("Delay after %d failures should be %v. Got: %v", index+1, delay, backoff.Delay(index+1))
+		}
+	}
+	if backoff.Delay(100) != 10*time.Second {
+		t.Error("Delay should be capped")
+	}
+
+	backoff.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := backoff.Delay(2); delay < time.Second || delay > 2*time.Second {
+			t.Error("Jittered delay out of bounds: ", delay)
+		}
+		if spread := backoff.Spread(10 * time.Second); spread < 0 || spread > 5*time.Second {
+			t.Error("Spread out of bounds: ", spread)
+		}
+	}
+}
+
+func TestSegmentWorkerFailureTime(t *testing.T) {
+	worker := &SegmentWorker{backoff: &service.Backoff{Base: 100 * time.Millisecond, Max: time.Second}}
+	if worker.FailureTime() != 0 {
+		t.Error("Failure time should be 0 before any failure")
+	}
+	worker.OnError(errors.New("connection refused"))
+	worker.OnError(errors.New("connection refused"))
+	if worker.FailureTime() != 200 {
+		t.Error("Failure time should grow with consecutive failures. Got: ", worker.FailureTime())
+	}
+}
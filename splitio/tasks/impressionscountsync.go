@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/asynctask"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func submitImpressionsCount(
+	impressionsCountStorage storage.ImpressionsCountStorageConsumer,
+	impressionsCountRecorder service.ImpressionsCountRecorder,
+	logger logging.LoggerInterface,
+	delivery *delivery,
+) error {
+	delivery.retry()
+
+	counts := impressionsCountStorage.PopCounts()
+	if len(counts) == 0 {
+		logger.Debug("No impression counts stored. Nothing to send")
+		return nil
+	}
+	return delivery.send(len(counts), func() error {
+		return impressionsCountRecorder.Record(dtos.ImpressionsCountDTO{PerFeature: counts})
+	})
+}
+
+// NewRecordImpressionsCountTask creates a new impression counts recording task
+func NewRecordImpressionsCountTask(
+	impressionsCountStorage storage.ImpressionsCountStorageConsumer,
+	impressionsCountRecorder service.ImpressionsCountRecorder,
+	period int,
+	logger logging.LoggerInterface,
+	status *TaskStatus,
+) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitImpressionsCount", defaultRetryBudget, defaultMaxAttempts, logger, status)
+	record := func(logger logging.LoggerInterface) error {
+		err := submitImpressionsCount(impressionsCountStorage, impressionsCountRecorder, logger, delivery)
+		status.Record(err)
+		return err
+	}
+
+	onStop := func(logger logging.LoggerInterface) {
+		// Flush counts so that nothing is lost on shutdown
+		record(logger)
+		delivery.flush()
+	}
+
+	return asynctask.NewAsyncTask("SubmitImpressionsCount", record, period, nil, onStop, logger)
+}
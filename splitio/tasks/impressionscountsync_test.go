@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/splitio/go-client/splitio"
+	"github.com/splitio/go-client/splitio/conf"
+	"github.com/splitio/go-client/splitio/service/api"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-client/splitio/storage/mutexmap"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+func TestSubmitImpressionsCount(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/testImpressions/count" || r.Method != "POST" {
+			t.Error("Invalid request. Should be POST to /testImpressions/count")
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+
+		var counts dtos.ImpressionsCountDTO
+		err := json.Unmarshal(body, &counts)
+		if err != nil {
+			t.Errorf("Error parsing json: %s", err)
+			return
+		}
+
+		if len(counts.PerFeature) != 1 || counts.PerFeature[0].FeatureName != "feature1" || counts.PerFeature[0].RawCount != 3 {
+			t.Error("Incorrect counts received: ", counts)
+		}
+	}))
+	defer ts.Close()
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	recorder := api.NewHTTPImpressionsCountRecorder(
+		"",
+		&conf.SplitSdkConfig{Advanced: conf.AdvancedConfig{EventsURL: ts.URL, SdkURL: ts.URL}},
+		&splitio.SdkMetadata{SDKVersion: "go-0.1"},
+		logger,
+	)
+
+	countStorage := mutexmap.NewMMImpressionsCountStorage()
+	delivery := newDelivery("SubmitImpressionsCount", defaultRetryBudget, defaultMaxAttempts, logger, nil)
+	err := submitImpressionsCount(countStorage, recorder, logger, delivery)
+	if err != nil || requests != 0 {
+		t.Error("Nothing should be posted if there are no counts")
+	}
+
+	countStorage.IncCounts(map[storage.ImpressionsCountKey]int64{{FeatureName: "feature1", TimeFrame: 3600000}: 3})
+	err = submitImpressionsCount(countStorage, recorder, logger, delivery)
+	if err != nil || requests != 1 {
+		t.Error("Counts should have been posted. Error: ", err)
+	}
+}
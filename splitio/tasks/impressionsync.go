@@ -14,7 +14,15 @@ func submitImpressions(
 	impressionRecorder service.ImpressionsRecorder,
 	logger logging.LoggerInterface,
 	bulkSize int64,
+	delivery *delivery,
 ) error {
+	if spooled, ok := impressionStorage.(storage.ImpressionSpoolConsumer); ok {
+		return submitSpooledImpressions(spooled, impressionRecorder, logger, bulkSize, delivery)
+	}
+
+	// Bulks that failed before go first, provided their backoff has elapsed
+	delivery.retry()
+
 	queuedImpressions, err := impressionStorage.PopN(bulkSize)
 	if err != nil {
 		logger.Error("Error reading impressions queue", err)
@@ -26,7 +34,36 @@ func submitImpressions(
 		return nil
 	}
 
-	return impressionRecorder.Record(queuedImpressions)
+	return delivery.send(len(queuedImpressions), func() error {
+		return impressionRecorder.Record(queuedImpressions)
+	})
+}
+
+// submitSpooledImpressions posts impressions read from a durable storage, which keeps them until they're posted
+func submitSpooledImpressions(
+	impressionStorage storage.ImpressionSpoolConsumer,
+	impressionRecorder service.ImpressionsRecorder,
+	logger logging.LoggerInterface,
+	bulkSize int64,
+	delivery *delivery,
+) error {
+	spooledImpressions, records, err := impressionStorage.PeekN(bulkSize)
+	if err != nil {
+		logger.Error("Error reading impressions spool", err)
+		return errors.New("Error reading impressions spool")
+	}
+
+	if records == 0 {
+		logger.Debug("No impressions fetched from spool. Nothing to send")
+		return nil
+	}
+
+	return delivery.sendSpooled(records, func() error {
+		if len(spooledImpressions) == 0 {
+			return nil
+		}
+		return impressionRecorder.Record(spooledImpressions)
+	}, impressionStorage.Ack)
 }
 
 // NewRecordImpressionsTask creates a new splits fetching and storing task
@@ -36,19 +73,31 @@ func NewRecordImpressionsTask(
 	period int,
 	logger logging.LoggerInterface,
 	bulkSize int64,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitImpressions", defaultRetryBudget, defaultMaxAttempts, logger, status)
 	record := func(logger logging.LoggerInterface) error {
-		return submitImpressions(
+		err := submitImpressions(
 			impressionStorage,
 			impressionRecorder,
 			logger,
 			bulkSize,
+			delivery,
 		)
+		status.Record(err)
+		return err
 	}
 
 	onStop := func(logger logging.LoggerInterface) {
 		// All this function does is flush impressions which will clear the storage
 		record(logger)
+		delivery.flush()
+
+		if spool, ok := impressionStorage.(storage.ImpressionSpoolConsumer); ok {
+			if err := spool.Close(); err != nil {
+				logger.Error("Error closing impressions spool", err)
+			}
+		}
 	}
 
 	return asynctask.NewAsyncTask("SubmitImpressions", record, period, nil, onStop, logger)
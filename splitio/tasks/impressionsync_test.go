@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -12,8 +13,10 @@ import (
 	"github.com/splitio/go-client/splitio"
 	"github.com/splitio/go-client/splitio/conf"
 	"github.com/splitio/go-client/splitio/service/api"
+	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-client/splitio/storage"
 	"github.com/splitio/go-client/splitio/storage/mutexqueue"
+	"github.com/splitio/go-client/splitio/storage/spool"
 	"github.com/splitio/go-toolkit/logging"
 )
 
@@ -91,6 +94,7 @@ func TestImpressionSyncTask(t *testing.T) {
 		1,
 		logger,
 		100,
+		nil,
 	)
 
 	impressionTask.Start()
@@ -174,6 +178,7 @@ func TestImpressionsFlushWhenTaskIsStopped(t *testing.T) {
 		100,
 		logger,
 		100,
+		nil,
 	)
 
 	impressionTask.Start()
@@ -202,3 +207,86 @@ func TestImpressionsFlushWhenTaskIsStopped(t *testing.T) {
 		t.Error("Impression Task should have ran twice")
 	}
 }
+
+type statusImpressionRecorder struct {
+	err      error
+	recorded int
+}
+
+func (r *statusImpressionRecorder) Record(impressions []storage.Impression) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.recorded += len(impressions)
+	return nil
+}
+
+func TestSubmitSpooledImpressions(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	impressionsSpool, err := spool.Open(dir, &conf.SpoolConfig{MaxSize: 4096, SegmentSize: 1024, SyncPolicy: conf.SpoolSyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer impressionsSpool.Close()
+	impressionStorage := spool.NewImpressionsStorage(impressionsSpool, logger)
+	impressionStorage.LogImpressions([]storage.Impression{
+		{FeatureName: "feature1", KeyName: "key1", Treatment: "on"},
+		{FeatureName: "feature1", KeyName: "key2", Treatment: "off"},
+	})
+
+	recorder := &statusImpressionRecorder{err: &dtos.HTTPError{Method: "POST", Code: 503, Message: "unavailable"}}
+	status := NewTaskStatus()
+	d := newDelivery("test", 100, 3, logger, status)
+	if submitImpressions(impressionStorage, recorder, logger, 10, d) == nil {
+		t.Error("Retryable error should be returned")
+	}
+	if impressionStorage.Count() != 2 || status.Get().HeldItems != 0 {
+		t.Error("Impressions should be kept in the spool rather than held in memory", impressionStorage.Count())
+	}
+
+	recorder.err = nil
+	submitImpressions(impressionStorage, recorder, logger, 10, d)
+	if recorder.recorded != 2 || !impressionStorage.Empty() {
+		t.Error("Spooled impressions should be posted and acknowledged", recorder.recorded, impressionStorage.Count())
+	}
+
+	impressionStorage.LogImpressions([]storage.Impression{{FeatureName: "feature1", KeyName: "key3", Treatment: "on"}})
+	recorder.err = &dtos.HTTPError{Method: "POST", Code: 400, Message: "bad request"}
+	submitImpressions(impressionStorage, recorder, logger, 10, d)
+	if !impressionStorage.Empty() || status.Get().DroppedItems != 1 {
+		t.Error("Impressions rejected by the server should be acknowledged and dropped")
+	}
+}
+
+func TestSpooledImpressionsTaskClosesSpool(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	impressionsSpool, err := spool.Open(dir, &conf.SpoolConfig{MaxSize: 4096, SegmentSize: 1024, SyncPolicy: conf.SpoolSyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	impressionStorage := spool.NewImpressionsStorage(impressionsSpool, logger)
+	recorder := &statusImpressionRecorder{}
+	task := NewRecordImpressionsTask(impressionStorage, recorder, 60, logger, 10, nil)
+	task.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	impressionStorage.LogImpressions([]storage.Impression{{FeatureName: "feature1", KeyName: "key1", Treatment: "on"}})
+	task.Stop()
+	for i := 0; i < 20 && task.IsRunning(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if recorder.recorded != 1 || !impressionStorage.Empty() {
+		t.Error("Spooled impressions should be posted before stopping", recorder.recorded)
+	}
+	err = impressionStorage.LogImpressions([]storage.Impression{{FeatureName: "feature1", KeyName: "key2", Treatment: "on"}})
+	if err != spool.ErrorClosed {
+		t.Error("Spool should be closed once the task stops. Got: ", err)
+	}
+}
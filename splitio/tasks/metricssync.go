@@ -11,11 +11,14 @@ import (
 func submitCounters(
 	metricsStorage storage.MetricsStorageConsumer,
 	metricsRecorder service.MetricsRecorder,
+	delivery *delivery,
 ) error {
+	delivery.retry()
 	counters := metricsStorage.PopCounters()
 	if len(counters) > 0 {
-		err := metricsRecorder.RecordCounters(counters)
-		return err
+		return delivery.send(len(counters), func() error {
+			return metricsRecorder.RecordCounters(counters)
+		})
 	}
 	return nil
 }
@@ -23,10 +26,15 @@ func submitCounters(
 func submitGauges(
 	metricsStorage storage.MetricsStorageConsumer,
 	metricsRecorder service.MetricsRecorder,
+	delivery *delivery,
 ) error {
+	delivery.retry()
 	var errs []error
 	for _, gauge := range metricsStorage.PopGauges() {
-		err := metricsRecorder.RecordGauge(gauge)
+		gauge := gauge
+		err := delivery.send(1, func() error {
+			return metricsRecorder.RecordGauge(gauge)
+		})
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -40,11 +48,14 @@ func submitGauges(
 func submitLatencies(
 	metricsStorage storage.MetricsStorageConsumer,
 	metricsRecorder service.MetricsRecorder,
+	delivery *delivery,
 ) error {
+	delivery.retry()
 	latencies := metricsStorage.PopLatencies()
 	if len(latencies) > 0 {
-		err := metricsRecorder.RecordLatencies(latencies)
-		return err
+		return delivery.send(len(latencies), func() error {
+			return metricsRecorder.RecordLatencies(latencies)
+		})
 	}
 	return nil
 }
@@ -55,16 +66,22 @@ func NewRecordCountersTask(
 	metricsRecorder service.MetricsRecorder,
 	period int,
 	logger logging.LoggerInterface,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitCounters", defaultRetryBudget, defaultMaxAttempts, logger, status)
 	record := func(logger logging.LoggerInterface) error {
-		return submitCounters(
+		err := submitCounters(
 			metricsStorage,
 			metricsRecorder,
+			delivery,
 		)
+		status.Record(err)
+		return err
 	}
 
 	onStop := func(l logging.LoggerInterface) {
 		record(logger)
+		delivery.flush()
 	}
 	return asynctask.NewAsyncTask("SubmitCounters", record, period, nil, onStop, logger)
 }
@@ -75,16 +92,22 @@ func NewRecordGaugesTask(
 	metricsRecorder service.MetricsRecorder,
 	period int,
 	logger logging.LoggerInterface,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitGauges", defaultRetryBudget, defaultMaxAttempts, logger, status)
 	record := func(logger logging.LoggerInterface) error {
-		return submitGauges(
+		err := submitGauges(
 			metricsStorage,
 			metricsRecorder,
+			delivery,
 		)
+		status.Record(err)
+		return err
 	}
 
 	onStop := func(l logging.LoggerInterface) {
 		record(logger)
+		delivery.flush()
 	}
 
 	return asynctask.NewAsyncTask("SubmitGauges", record, period, nil, onStop, logger)
@@ -96,16 +119,22 @@ func NewRecordLatenciesTask(
 	metricsRecorder service.MetricsRecorder,
 	period int,
 	logger logging.LoggerInterface,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitLatencies", defaultRetryBudget, defaultMaxAttempts, logger, status)
 	record := func(logger logging.LoggerInterface) error {
-		return submitLatencies(
+		err := submitLatencies(
 			metricsStorage,
 			metricsRecorder,
+			delivery,
 		)
+		status.Record(err)
+		return err
 	}
 
 	onStop := func(l logging.LoggerInterface) {
 		record(logger)
+		delivery.flush()
 	}
 
 	return asynctask.NewAsyncTask("SubmitLatencies", record, period, nil, onStop, logger)
@@ -101,18 +101,21 @@ func TestMetricsSyncTask(t *testing.T) {
 		metricsRecorder,
 		1,
 		logger,
+		nil,
 	)
 	gaugesTask := NewRecordGaugesTask(
 		metricsStorage,
 		metricsRecorder,
 		1,
 		logger,
+		nil,
 	)
 	latenciesTask := NewRecordLatenciesTask(
 		metricsStorage,
 		metricsRecorder,
 		1,
 		logger,
+		nil,
 	)
 
 	countersTask.Start()
@@ -183,6 +186,7 @@ func TestMetricsFlushWhenTaskIsStopped(t *testing.T) {
 		metricsRecorder,
 		100,
 		logger,
+		nil,
 	)
 
 	counterTask := NewRecordCountersTask(
@@ -190,6 +194,7 @@ func TestMetricsFlushWhenTaskIsStopped(t *testing.T) {
 		metricsRecorder,
 		100,
 		logger,
+		nil,
 	)
 
 	latencyTask := NewRecordLatenciesTask(
@@ -197,6 +202,7 @@ func TestMetricsFlushWhenTaskIsStopped(t *testing.T) {
 		metricsRecorder,
 		100,
 		logger,
+		nil,
 	)
 
 	gaugeTask.Start()
@@ -0,0 +1,146 @@
+package tasks
+
+import (
+	"errors"
+
+	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/asynctask"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// submitQueuedImpressions pops impressions stored by any sdk instance sharing the queue and posts them
+// grouped by the instance that generated them
+func submitQueuedImpressions(
+	impressionStorage storage.ImpressionQueueConsumer,
+	impressionRecorder service.ImpressionsRecorderWithMetadata,
+	logger logging.LoggerInterface,
+	bulkSize int64,
+	delivery *delivery,
+) error {
+	// Bulks that failed before go first, provided their backoff has elapsed
+	delivery.retry()
+
+	queuedImpressions, err := impressionStorage.PopNWithMetadata(bulkSize)
+	if err != nil {
+		logger.Error("Error reading impressions queue", err)
+		return errors.New("Error reading impressions queue")
+	}
+
+	if len(queuedImpressions) == 0 {
+		logger.Debug("No impressions fetched from queue. Nothing to send")
+		return nil
+	}
+
+	impressionsByMetadata := make(map[dtos.QueueStoredMachineMetadataDTO][]storage.Impression)
+	for _, queued := range queuedImpressions {
+		impressionsByMetadata[queued.Metadata] = append(impressionsByMetadata[queued.Metadata], queued.Impression)
+	}
+
+	var lastErr error
+	for metadata, impressions := range impressionsByMetadata {
+		metadata, impressions := metadata, impressions
+		err := delivery.send(len(impressions), func() error {
+			return impressionRecorder.RecordWithMetadata(impressions, metadata)
+		})
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// NewRecordQueuedImpressionsTask creates a new task that drains an impressions queue shared by several sdk instances
+func NewRecordQueuedImpressionsTask(
+	impressionStorage storage.ImpressionQueueConsumer,
+	impressionRecorder service.ImpressionsRecorderWithMetadata,
+	period int,
+	logger logging.LoggerInterface,
+	bulkSize int64,
+	status *TaskStatus,
+) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitQueuedImpressions", defaultRetryBudget, defaultMaxAttempts, logger, status)
+	record := func(logger logging.LoggerInterface) error {
+		err := submitQueuedImpressions(impressionStorage, impressionRecorder, logger, bulkSize, delivery)
+		status.Record(err)
+		return err
+	}
+
+	onStop := func(logger logging.LoggerInterface) {
+		record(logger)
+		delivery.flush()
+	}
+
+	return asynctask.NewAsyncTask("SubmitQueuedImpressions", record, period, nil, onStop, logger)
+}
+
+// submitQueuedEvents pops events stored by any sdk instance sharing the queue and posts them
+// grouped by the instance that generated them
+func submitQueuedEvents(
+	eventStorage storage.EventQueueConsumer,
+	eventRecorder service.EventsRecorderWithMetadata,
+	bulkSize int64,
+	logger logging.LoggerInterface,
+	delivery *delivery,
+) error {
+	// Bulks that failed before go first, provided their backoff has elapsed
+	delivery.retry()
+
+	queuedEvents, err := eventStorage.PopNWithMetadata(bulkSize)
+	if err != nil {
+		logger.Error("Error reading events queue", err)
+		return errors.New("Error reading events queue")
+	}
+
+	if len(queuedEvents) == 0 {
+		logger.Debug("No events fetched from queue. Nothing to send")
+		return nil
+	}
+
+	eventsByMetadata := make(map[dtos.QueueStoredMachineMetadataDTO][]dtos.EventDTO)
+	for _, queued := range queuedEvents {
+		eventsByMetadata[queued.Metadata] = append(eventsByMetadata[queued.Metadata], queued.Event)
+	}
+
+	var lastErr error
+	for metadata, events := range eventsByMetadata {
+		metadata, events := metadata, events
+		err := delivery.send(len(events), func() error {
+			return eventRecorder.RecordWithMetadata(events, metadata)
+		})
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// NewRecordQueuedEventsTask creates a new task that drains an events queue shared by several sdk instances
+func NewRecordQueuedEventsTask(
+	eventStorage storage.EventQueueConsumer,
+	eventRecorder service.EventsRecorderWithMetadata,
+	bulkSize int64,
+	period int,
+	logger logging.LoggerInterface,
+	status *TaskStatus,
+) *asynctask.AsyncTask {
+	delivery := newDelivery("SubmitQueuedEvents", defaultRetryBudget, defaultMaxAttempts, logger, status)
+	record := func(logger logging.LoggerInterface) error {
+		err := submitQueuedEvents(eventStorage, eventRecorder, bulkSize, logger, delivery)
+		status.Record(err)
+		return err
+	}
+
+	onStop := func(logger logging.LoggerInterface) {
+		// Flush whatever is left in the queue, giving up on the first failure so that shutdown isn't blocked
+		for !eventStorage.Empty() {
+			if record(logger) != nil {
+				break
+			}
+		}
+		delivery.flush()
+	}
+
+	return asynctask.NewAsyncTask("SubmitQueuedEvents", record, period, nil, onStop, logger)
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/splitio/go-client/splitio/service/dtos"
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+type impressionQueueMock struct {
+	queued []storage.ImpressionQueueObject
+}
+
+func (m *impressionQueueMock) PopNWithMetadata(n int64) ([]storage.ImpressionQueueObject, error) {
+	if int64(len(m.queued)) < n {
+		n = int64(len(m.queued))
+	}
+	popped := m.queued[:n]
+	m.queued = m.queued[n:]
+	return popped, nil
+}
+
+type impressionRecorderWithMetadataMock struct {
+	recorded map[dtos.QueueStoredMachineMetadataDTO][]storage.Impression
+}
+
+func (m *impressionRecorderWithMetadataMock) RecordWithMetadata(
+	impressions []storage.Impression,
+	metadata dtos.QueueStoredMachineMetadataDTO,
+) error {
+	m.recorded[metadata] = append(m.recorded[metadata], impressions...)
+	return nil
+}
+
+type eventQueueMock struct {
+	queued []dtos.QueueStoredEventDTO
+}
+
+func (m *eventQueueMock) PopNWithMetadata(n int64) ([]dtos.QueueStoredEventDTO, error) {
+	if int64(len(m.queued)) < n {
+		n = int64(len(m.queued))
+	}
+	popped := m.queued[:n]
+	m.queued = m.queued[n:]
+	return popped, nil
+}
+
+func (m *eventQueueMock) Empty() bool {
+	return len(m.queued) == 0
+}
+
+type eventRecorderWithMetadataMock struct {
+	fail     bool
+	recorded map[dtos.QueueStoredMachineMetadataDTO][]dtos.EventDTO
+}
+
+func (m *eventRecorderWithMetadataMock) RecordWithMetadata(
+	events []dtos.EventDTO,
+	metadata dtos.QueueStoredMachineMetadataDTO,
+) error {
+	if m.fail {
+		return errors.New("some error")
+	}
+	m.recorded[metadata] = append(m.recorded[metadata], events...)
+	return nil
+}
+
+func TestSubmitQueuedImpressions(t *testing.T) {
+	instance1 := dtos.QueueStoredMachineMetadataDTO{SDKVersion: "go-1", MachineIP: "1.1.1.1", MachineName: "m1"}
+	instance2 := dtos.QueueStoredMachineMetadataDTO{SDKVersion: "go-2", MachineIP: "2.2.2.2", MachineName: "m2"}
+
+	impressionStorage := &impressionQueueMock{
+		queued: []storage.ImpressionQueueObject{
+			{Metadata: instance1, Impression: storage.Impression{FeatureName: "f1", KeyName: "k1"}},
+			{Metadata: instance2, Impression: storage.Impression{FeatureName: "f1", KeyName: "k2"}},
+			{Metadata: instance1, Impression: storage.Impression{FeatureName: "f2", KeyName: "k3"}},
+		},
+	}
+	recorder := &impressionRecorderWithMetadataMock{
+		recorded: make(map[dtos.QueueStoredMachineMetadataDTO][]storage.Impression),
+	}
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	d := newDelivery("test", 100, 3, logger, nil)
+	err := submitQueuedImpressions(impressionStorage, recorder, logger, 100, d)
+	if err != nil {
+		t.Error("No error should be returned. Got: ", err)
+	}
+
+	if len(recorder.recorded[instance1]) != 2 || len(recorder.recorded[instance2]) != 1 {
+		t.Error("Impressions should have been grouped by instance. Got: ", recorder.recorded)
+	}
+
+	if len(impressionStorage.queued) != 0 {
+		t.Error("Queue should have been drained")
+	}
+}
+
+func TestSubmitQueuedEvents(t *testing.T) {
+	instance1 := dtos.QueueStoredMachineMetadataDTO{SDKVersion: "go-1", MachineIP: "1.1.1.1", MachineName: "m1"}
+	queued := make([]dtos.QueueStoredEventDTO, 0)
+	for i := 0; i < 10; i++ {
+		queued = append(queued, dtos.QueueStoredEventDTO{Metadata: instance1, Event: dtos.EventDTO{Key: "key"}})
+	}
+
+	eventStorage := &eventQueueMock{queued: queued}
+	recorder := &eventRecorderWithMetadataMock{recorded: make(map[dtos.QueueStoredMachineMetadataDTO][]dtos.EventDTO)}
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	status := NewTaskStatus()
+	d := newDelivery("test", 100, 3, logger, status)
+	err := submitQueuedEvents(eventStorage, recorder, 3, logger, d)
+	if err != nil || len(recorder.recorded[instance1]) != 3 {
+		t.Error("A single bulk should have been posted")
+	}
+
+	recorder.fail = true
+	err = submitQueuedEvents(eventStorage, recorder, 3, logger, d)
+	if err == nil {
+		t.Error("Recorder errors should be propagated")
+	}
+	if status.Get().HeldItems != 3 {
+		t.Error("Events popped by a failed post should be held for retrying. Got: ", status.Get().HeldItems)
+	}
+
+	recorder.fail = false
+	for !eventStorage.Empty() {
+		submitQueuedEvents(eventStorage, recorder, 3, logger, d)
+	}
+	d.flush()
+	if len(recorder.recorded[instance1]) != 10 || status.Get().HeldItems != 0 {
+		t.Error("Every event should have been posted. Got: ", len(recorder.recorded[instance1]))
+	}
+}
@@ -1,11 +1,14 @@
 package tasks
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/splitio/go-client/splitio/service"
+	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-client/splitio/storage"
 	"github.com/splitio/go-toolkit/asynctask"
 	"github.com/splitio/go-toolkit/datastructures/set"
@@ -13,13 +16,22 @@ import (
 	"github.com/splitio/go-toolkit/workerpool"
 )
 
+// updateSegment fetches & stores the changes of a segment. A cacheBuster change number (> 0) is used to skip
+// stale responses of caching proxies, if the fetcher supports it
 func updateSegment(
 	segmentFetcher service.SegmentFetcher,
 	segmentStorage storage.SegmentStorage,
 	name string,
+	cacheBuster int64,
 ) (bool, error) {
 	till := segmentStorage.Till(name)
-	segmentChanges, err := segmentFetcher.Fetch(name, till)
+	var segmentChanges *dtos.SegmentChangesDTO
+	var err error
+	if bypassFetcher, ok := segmentFetcher.(service.CacheBypassSegmentFetcher); ok && cacheBuster > 0 {
+		segmentChanges, err = bypassFetcher.FetchTill(name, till, cacheBuster)
+	} else {
+		segmentChanges, err = segmentFetcher.Fetch(name, till)
+	}
 	if err != nil {
 		return false, err
 	}
@@ -45,10 +57,47 @@ func updateSegment(
 	return segmentChanges.Since == segmentChanges.Till, nil
 }
 
+// SynchronizeSegment fetches changes for a single segment until it has caught up with the backend and, if a
+// target change number is supplied (> 0), until the storage reflects at least that change number. It gives up as
+// soon as ctx is done
+func SynchronizeSegment(
+	ctx context.Context,
+	segmentStorage storage.SegmentStorage,
+	segmentFetcher service.SegmentFetcher,
+	name string,
+	till int64,
+	maxAttempts int,
+) error {
+	var cacheBuster int64
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ready, err := updateSegment(segmentFetcher, segmentStorage, name, cacheBuster)
+		if err != nil {
+			return err
+		}
+		if ready && (till <= 0 || segmentStorage.Till(name) >= till) {
+			return nil
+		}
+		if ready {
+			// The backend claims to be up to date with an older change number, most likely a cached response
+			cacheBuster = till
+		}
+		if attempt >= maxAttempts {
+			return fmt.Errorf("segment %s not in sync with change number %d after %d attempts", name, till, maxAttempts)
+		}
+	}
+}
+
 // SegmentWorker struct contains resources and functions for fetching segments and storing them
 type SegmentWorker struct {
 	name           string
 	failureTime    int64
+	failures       int
+	backoff        *service.Backoff
+	syncLock       *SyncLock
+	status         *TaskStatus
 	segmentStorage storage.SegmentStorage
 	segmentFetcher service.SegmentFetcher
 }
@@ -60,6 +109,9 @@ func (w *SegmentWorker) Name() string {
 
 // FailureTime Returns how much time should be waited after an error, before the worker resumes execution
 func (w *SegmentWorker) FailureTime() int64 {
+	if w.backoff != nil && w.failures > 0 {
+		return int64(w.backoff.Delay(w.failures) / time.Millisecond)
+	}
 	return w.failureTime
 }
 
@@ -70,12 +122,22 @@ func (w *SegmentWorker) DoWork(msg interface{}) error {
 		return errors.New("segment name popped from queue is not a string")
 	}
 
-	_, err := updateSegment(w.segmentFetcher, w.segmentStorage, segmentName)
+	w.syncLock.lockPeriodic()
+	_, err := updateSegment(w.segmentFetcher, w.segmentStorage, segmentName, 0)
+	w.syncLock.unlockPeriodic()
+	if err == nil {
+		w.failures = 0
+	}
+	w.status.Record(err)
 	return err
 }
 
-// OnError callback does nothing
-func (w *SegmentWorker) OnError(e error) {}
+// OnError callback counts consecutive retryable failures, which increase the time waited before resuming
+func (w *SegmentWorker) OnError(e error) {
+	if isRetryable(e) {
+		w.failures++
+	}
+}
 
 // Cleanup callback does nothing
 func (w *SegmentWorker) Cleanup() error { return nil }
@@ -103,7 +165,9 @@ func updateSegments(
 	return nil
 }
 
-// NewFetchSegmentsTask creates a new segment fetching and storing task
+// NewFetchSegmentsTask creates a new segment fetching and storing task. When the initial fetch fails, the task
+// stops unless keepPolling is set, in which case SEGMENTS_ERROR is reported and periodic fetches keep retrying.
+// Fetches wait for manual synchronizations holding syncLock
 func NewFetchSegmentsTask(
 	splitStorage storage.SplitStorageConsumer,
 	segmentStorage storage.SegmentStorage,
@@ -113,14 +177,20 @@ func NewFetchSegmentsTask(
 	queueSize int,
 	logger logging.LoggerInterface,
 	readyChannel chan string,
+	keepPolling bool,
+	syncLock *SyncLock,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
 	admin := workerpool.NewWorkerAdmin(queueSize, logger)
+	backoff := fetchBackoff(segmentFetcher)
+	var retrier *fetchRetrier
 
 	init := func(logger logging.LoggerInterface) error {
 		segmentNames := splitStorage.SegmentNames().List()
 		wg := sync.WaitGroup{}
 		wg.Add(len(segmentNames))
 		failedSegments := make([]string, 0)
+		failedMutex := sync.Mutex{}
 		for _, name := range segmentNames {
 			conv, ok := name.(string)
 			if !ok {
@@ -131,19 +201,33 @@ func NewFetchSegmentsTask(
 				defer wg.Done() // Make sure the "finished" signal is always sent
 				ready := false
 				var err error
-				for !ready {
-					ready, err = updateSegment(segmentFetcher, segmentStorage, segmentName)
-					if err != nil {
+				for attempt := 1; !ready; {
+					syncLock.lockPeriodic()
+					ready, err = updateSegment(segmentFetcher, segmentStorage, segmentName, 0)
+					syncLock.unlockPeriodic()
+					if err == nil {
+						continue
+					}
+					if backoff == nil || !isRetryable(err) || attempt >= backoff.InitAttempts {
+						failedMutex.Lock()
 						failedSegments = append(failedSegments, segmentName)
+						failedMutex.Unlock()
 						return
 					}
+					time.Sleep(backoff.Delay(attempt))
+					attempt++
 				}
 			}(conv)
 		}
 		wg.Wait()
 
+		var err error
 		if len(failedSegments) > 0 {
-			return fmt.Errorf("The following segments failed to be fetched %v", failedSegments)
+			err = fmt.Errorf("The following segments failed to be fetched %v", failedSegments)
+		}
+		status.Record(err)
+		if err != nil && !keepPolling {
+			return err
 		}
 
 		// After all segments are in sync, add workers to the pool that will keep them up to date
@@ -152,16 +236,25 @@ func NewFetchSegmentsTask(
 			admin.AddWorker(&SegmentWorker{
 				name:           fmt.Sprintf("SegmentWorker_%d", i),
 				failureTime:    0,
+				backoff:        backoff,
+				syncLock:       syncLock,
+				status:         status,
 				segmentFetcher: segmentFetcher,
 				segmentStorage: segmentStorage,
 			})
 		}
 
+		if err != nil {
+			logger.Error(err.Error())
+			readyChannel <- "SEGMENTS_ERROR"
+			return nil
+		}
 		readyChannel <- "SEGMENTS_READY"
 		return nil
 	}
 
 	update := func(logger logging.LoggerInterface) error {
+		retrier.wait()
 		return updateSegments(splitStorage, admin, logger)
 	}
 
@@ -169,5 +262,7 @@ func NewFetchSegmentsTask(
 		admin.StopAll()
 	}
 
-	return asynctask.NewAsyncTask("UpdateSegments", update, period, init, cleanup, logger)
+	task := asynctask.NewAsyncTask("UpdateSegments", update, period, init, cleanup, logger)
+	retrier = newFetchRetrier(backoff, period, task)
+	return task
 }
@@ -126,6 +126,9 @@ func TestSegmentSyncTask(t *testing.T) {
 		100,
 		logger,
 		readyChannel,
+		false,
+		nil,
+		nil,
 	)
 
 	segmentTask.Start()
@@ -0,0 +1,34 @@
+package tasks
+
+import (
+	"github.com/splitio/go-client/splitio/storage"
+	"github.com/splitio/go-client/splitio/storage/snapshot"
+	"github.com/splitio/go-toolkit/asynctask"
+	"github.com/splitio/go-toolkit/logging"
+)
+
+// NewWriteSnapshotTask creates a task that periodically writes a snapshot of the split & segment storages to a
+// file, along with a last one when stopped
+func NewWriteSnapshotTask(
+	splitStorage storage.SplitStorage,
+	segmentStorage storage.SegmentStorage,
+	path string,
+	period int,
+	logger logging.LoggerInterface,
+	status *TaskStatus,
+) *asynctask.AsyncTask {
+	write := func(logger logging.LoggerInterface) error {
+		err := snapshot.Take(splitStorage, segmentStorage).Write(path)
+		if err != nil {
+			logger.Error("Error writing snapshot to ", path, ": ", err)
+		}
+		status.Record(err)
+		return err
+	}
+
+	onStop := func(logger logging.LoggerInterface) {
+		write(logger)
+	}
+
+	return asynctask.NewAsyncTask("WriteSnapshot", write, period, nil, onStop, logger)
+}
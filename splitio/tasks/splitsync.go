@@ -1,6 +1,10 @@
 package tasks
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/splitio/go-client/splitio/service"
 	"github.com/splitio/go-client/splitio/service/dtos"
 	"github.com/splitio/go-client/splitio/storage"
@@ -8,13 +12,25 @@ import (
 	"github.com/splitio/go-toolkit/logging"
 )
 
-func updateSplits(splitStorage storage.SplitStorageProducer, splitFetcher service.SplitFetcher) (bool, error) {
+// updateSplits fetches & stores split changes. A cacheBuster change number (> 0) is used to skip stale responses
+// of caching proxies, if the fetcher supports it
+func updateSplits(
+	splitStorage storage.SplitStorageProducer,
+	splitFetcher service.SplitFetcher,
+	cacheBuster int64,
+) (bool, error) {
 	till := splitStorage.Till()
 	if till == 0 {
 		till = -1
 	}
 
-	splits, err := splitFetcher.Fetch(till)
+	var splits *dtos.SplitChangesDTO
+	var err error
+	if bypassFetcher, ok := splitFetcher.(service.CacheBypassSplitFetcher); ok && cacheBuster > 0 {
+		splits, err = bypassFetcher.FetchTill(till, cacheBuster)
+	} else {
+		splits, err = splitFetcher.Fetch(till)
+	}
 	if err != nil {
 		return false, err
 	}
@@ -43,32 +59,102 @@ func updateSplits(splitStorage storage.SplitStorageProducer, splitFetcher servic
 	return false, nil
 }
 
-// NewFetchSplitsTask creates a new splits fetching and storing task
+// SynchronizeSplits fetches split changes until the storage has caught up with the backend and, if a target
+// change number is supplied (> 0), until the storage reflects at least that change number. It gives up as soon as
+// ctx is done
+func SynchronizeSplits(
+	ctx context.Context,
+	splitStorage storage.SplitStorageProducer,
+	splitFetcher service.SplitFetcher,
+	till int64,
+	maxAttempts int,
+) error {
+	var cacheBuster int64
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ready, err := updateSplits(splitStorage, splitFetcher, cacheBuster)
+		if err != nil {
+			return err
+		}
+		if ready && (till <= 0 || splitStorage.Till() >= till) {
+			return nil
+		}
+		if ready {
+			// The backend claims to be up to date with an older change number, most likely a cached response
+			cacheBuster = till
+		}
+		if attempt >= maxAttempts {
+			return fmt.Errorf("splits not in sync with change number %d after %d attempts", till, maxAttempts)
+		}
+	}
+}
+
+// fetchBackoff returns the retry policy of a fetcher, or nil if failed fetches should not be retried
+func fetchBackoff(fetcher interface{}) *service.Backoff {
+	if backoffFetcher, ok := fetcher.(service.BackoffFetcher); ok {
+		return backoffFetcher.Backoff()
+	}
+	return nil
+}
+
+// NewFetchSplitsTask creates a new splits fetching and storing task. When the initial fetch fails, the task stops
+// unless keepPolling is set, in which case periodic fetches keep retrying (ie: when definitions are already served
+// from a snapshot). Fetches wait for manual synchronizations holding syncLock
 func NewFetchSplitsTask(
 	splitStorage storage.SplitStorageProducer,
 	splitFetcher service.SplitFetcher,
 	period int,
 	logger logging.LoggerInterface,
 	readyChannel chan string,
+	keepPolling bool,
+	syncLock *SyncLock,
+	status *TaskStatus,
 ) *asynctask.AsyncTask {
+	backoff := fetchBackoff(splitFetcher)
+	var task *asynctask.AsyncTask
+	var retrier *fetchRetrier
+
 	init := func(logger logging.LoggerInterface) error {
 		ready := false
 		var err error
-		for !ready {
-			ready, err = updateSplits(splitStorage, splitFetcher)
-			if err != nil {
+		for attempt := 1; !ready; {
+			syncLock.lockPeriodic()
+			ready, err = updateSplits(splitStorage, splitFetcher, 0)
+			syncLock.unlockPeriodic()
+			if err == nil {
+				continue
+			}
+			if backoff == nil || !isRetryable(err) || attempt >= backoff.InitAttempts {
+				status.Record(err)
 				readyChannel <- "SPLITS_ERROR"
+				if keepPolling {
+					return nil
+				}
 				return err
 			}
+			delay := backoff.Delay(attempt)
+			logger.Warning(fmt.Sprintf("Error fetching splits (attempt %d), retrying in %v: %s", attempt, delay, err.Error()))
+			time.Sleep(delay)
+			attempt++
 		}
+		status.Record(nil)
 		readyChannel <- "SPLITS_READY"
 		return nil
 	}
 
 	update := func(logger logging.LoggerInterface) error {
-		_, err := updateSplits(splitStorage, splitFetcher)
+		retrier.wait()
+		syncLock.lockPeriodic()
+		_, err := updateSplits(splitStorage, splitFetcher, 0)
+		syncLock.unlockPeriodic()
+		retrier.done(err)
+		status.Record(err)
 		return err
 	}
 
-	return asynctask.NewAsyncTask("UpdateSplits", update, period, init, nil, logger)
+	task = asynctask.NewAsyncTask("UpdateSplits", update, period, init, nil, logger)
+	retrier = newFetchRetrier(backoff, period, task)
+	return task
 }
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -65,6 +66,9 @@ func TestSplitSyncTask(t *testing.T) {
 		3,
 		logger,
 		readyChannel,
+		false,
+		nil,
+		nil,
 	)
 
 	splitTask.Start()
@@ -187,7 +191,7 @@ func TestSplitSyncTaskStatus(t *testing.T) {
 	splitStorage := mutexmap.NewMMSplitStorage()
 	splitStorage.PutMany([]dtos.SplitDTO{{}}, -1)
 
-	updateSplits(splitStorage, splitFetcher)
+	updateSplits(splitStorage, splitFetcher, 0)
 
 	if !splitStorage.TrafficTypeExists("one") {
 		t.Error("It should exists")
@@ -208,7 +212,7 @@ func TestSplitSyncTaskStatus(t *testing.T) {
 		logger,
 	)
 
-	updateSplits(splitStorage, splitFetcher2)
+	updateSplits(splitStorage, splitFetcher2, 0)
 
 	s1 := splitStorage.Get("split1")
 	if s1 != nil {
@@ -240,3 +244,76 @@ func TestSplitSyncTaskStatus(t *testing.T) {
 		t.Error("It should exists")
 	}
 }
+
+type cachingSplitFetcher struct {
+	cacheBusters []int64
+}
+
+func (f *cachingSplitFetcher) Fetch(changeNumber int64) (*dtos.SplitChangesDTO, error) {
+	// Stale response served by a caching proxy
+	return &dtos.SplitChangesDTO{Since: 5, Till: 5, Splits: []dtos.SplitDTO{}}, nil
+}
+
+func (f *cachingSplitFetcher) FetchTill(changeNumber int64, till int64) (*dtos.SplitChangesDTO, error) {
+	f.cacheBusters = append(f.cacheBusters, till)
+	if changeNumber < 10 {
+		return &dtos.SplitChangesDTO{Since: changeNumber, Till: 10, Splits: []dtos.SplitDTO{
+			{Name: "split1", Status: "ACTIVE", TrafficTypeName: "one"},
+		}}, nil
+	}
+	return &dtos.SplitChangesDTO{Since: 10, Till: 10, Splits: []dtos.SplitDTO{}}, nil
+}
+
+func TestSynchronizeSplitsBypassesCache(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{}, 5)
+	fetcher := &cachingSplitFetcher{}
+
+	if err := SynchronizeSplits(context.Background(), splitStorage, fetcher, 10, 5); err != nil {
+		t.Error("Splits should be in sync", err)
+	}
+	if splitStorage.Till() != 10 || splitStorage.Get("split1") == nil {
+		t.Error("Changes should have been fetched bypassing the cache")
+	}
+	if len(fetcher.cacheBusters) != 2 || fetcher.cacheBusters[0] != 10 {
+		t.Error("Till should have been sent after a stale response", fetcher.cacheBusters)
+	}
+}
+
+func TestSynchronizeSplitsCancelled(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage()
+	splitStorage.PutMany([]dtos.SplitDTO{}, 5)
+	fetcher := &cachingSplitFetcher{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := SynchronizeSplits(ctx, splitStorage, fetcher, 10, 5); err != context.Canceled {
+		t.Error("Context error should be returned. Got: ", err)
+	}
+	if splitStorage.Till() != 5 || len(fetcher.cacheBusters) != 0 {
+		t.Error("Nothing should be fetched once the context is done")
+	}
+}
+
+func TestSplitsFetchWaitsForSyncLock(t *testing.T) {
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	fetcher := &backoffSplitFetcher{}
+	syncLock := NewSyncLock()
+
+	syncLock.Lock()
+	readyChannel := make(chan string, 1)
+	task := NewFetchSplitsTask(mutexmap.NewMMSplitStorage(), fetcher, 60, logger, readyChannel, false, syncLock, nil)
+	task.Start()
+	defer task.Stop()
+
+	select {
+	case <-readyChannel:
+		t.Error("Splits should not be fetched while a manual synchronization holds the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	syncLock.Unlock()
+	if msg := <-readyChannel; msg != "SPLITS_READY" {
+		t.Error("Splits should be fetched once the lock is released. Got: ", msg)
+	}
+}
@@ -0,0 +1,66 @@
+package tasks
+
+import (
+	"sync"
+	"time"
+)
+
+// Status holds the outcome of the last runs of a synchronization task
+type Status struct {
+	LastSuccess time.Time
+	LastFailure time.Time
+	// LastError is the error of the last failed run, or nil if the last run succeeded
+	LastError error
+	// HeldItems is how many items failed to be posted and are waiting to be posted again
+	HeldItems int
+	// DroppedItems is how many items were given up on after failing to be posted
+	DroppedItems int64
+}
+
+// TaskStatus keeps track of the outcome of a synchronization task. A nil *TaskStatus discards outcomes, so tasks
+// can be created without one
+type TaskStatus struct {
+	status Status
+	mutex  sync.Mutex
+}
+
+// NewTaskStatus instantiates a task status with no runs recorded
+func NewTaskStatus() *TaskStatus {
+	return &TaskStatus{}
+}
+
+// Record stores the outcome of a run
+func (s *TaskStatus) Record(err error) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil {
+		s.status.LastFailure = time.Now()
+	} else {
+		s.status.LastSuccess = time.Now()
+	}
+	s.status.LastError = err
+}
+
+// recordDelivery stores the amount of items held for retrying and dropped by the task
+func (s *TaskStatus) recordDelivery(held int, dropped int64) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status.HeldItems = held
+	s.status.DroppedItems = dropped
+}
+
+// Get returns a copy of the outcome of the last runs
+func (s *TaskStatus) Get() Status {
+	if s == nil {
+		return Status{}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status
+}
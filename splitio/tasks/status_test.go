@@ -0,0 +1,34 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTaskStatus(t *testing.T) {
+	var discarded *TaskStatus
+	discarded.Record(errors.New("some"))
+	if !discarded.Get().LastFailure.IsZero() {
+		t.Error("Nil statuses should discard outcomes")
+	}
+
+	status := NewTaskStatus()
+	status.Record(nil)
+	if current := status.Get(); current.LastSuccess.IsZero() || !current.LastFailure.IsZero() || current.LastError != nil {
+		t.Error("Success should be recorded", current)
+	}
+
+	status.Record(errors.New("some"))
+	current := status.Get()
+	if current.LastFailure.IsZero() || current.LastError == nil || current.LastError.Error() != "some" {
+		t.Error("Failure should be recorded", current)
+	}
+	if current.LastSuccess.IsZero() {
+		t.Error("Last success should be kept after a failure")
+	}
+
+	status.Record(nil)
+	if current = status.Get(); current.LastError != nil || current.LastFailure.IsZero() {
+		t.Error("Error should be cleared while keeping the failure time", current)
+	}
+}
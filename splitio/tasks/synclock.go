@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"sync"
+)
+
+// SyncLock serializes manual synchronizations with the periodic split & segment fetches. Periodic fetches still
+// run concurrently with each other. A nil *SyncLock doesn't serialize anything, so tasks can be created without one
+type SyncLock struct {
+	mutex sync.RWMutex
+}
+
+// NewSyncLock instantiates a sync lock
+func NewSyncLock() *SyncLock {
+	return &SyncLock{}
+}
+
+// Lock waits for the running periodic fetches to finish and holds off new ones until Unlock is called
+func (l *SyncLock) Lock() {
+	if l != nil {
+		l.mutex.Lock()
+	}
+}
+
+// Unlock lets periodic fetches run again
+func (l *SyncLock) Unlock() {
+	if l != nil {
+		l.mutex.Unlock()
+	}
+}
+
+// lockPeriodic waits for a running manual synchronization to finish before a periodic fetch
+func (l *SyncLock) lockPeriodic() {
+	if l != nil {
+		l.mutex.RLock()
+	}
+}
+
+// unlockPeriodic releases the lock taken by lockPeriodic
+func (l *SyncLock) unlockPeriodic() {
+	if l != nil {
+		l.mutex.RUnlock()
+	}
+}